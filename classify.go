@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+
+	"github.com/Grimkey/nanollm/src/datasets"
+	"github.com/Grimkey/nanollm/src/micrograd"
+	"github.com/Grimkey/nanollm/src/plot"
+)
+
+// runClassify is the micrograd demo: train a small MLP on a 2D toy dataset
+// with a max-margin loss and draw the decision boundary it learned.
+func runClassify(args []string) error {
+	fs := flag.NewFlagSet("classify", flag.ContinueOnError)
+	name := fs.String("dataset", "moons", fmt.Sprintf("dataset: one of %v", datasets.Names()))
+	n := fs.Int("n", 100, "number of points")
+	noise := fs.Float64("noise", 0.1, "standard deviation of the noise added to the points")
+	hidden := fs.String("hidden", "16,16", "comma separated hidden layer sizes")
+	steps := fs.Int("steps", 100, "number of full batch gradient descent steps")
+	lr := fs.Float64("lr", 1.0, "initial learning rate, decayed linearly to a tenth of it")
+	alpha := fs.Float64("alpha", 1e-4, "L2 regularization strength")
+	seed := fs.Int64("seed", 1337, "random seed for the data and the weights")
+	out := fs.String("out", "boundary.png", "output PNG")
+	cell := fs.Int("cell", 4, "pixel size of the grid the boundary is evaluated on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	layers, err := parseInts(*hidden)
+	if err != nil {
+		return fmt.Errorf("-hidden: %w", err)
+	}
+	if *steps <= 0 {
+		return fmt.Errorf("-steps must be positive, got %d", *steps)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	data, err := datasets.Generate(*name, *n, *noise, rng)
+	if err != nil {
+		return err
+	}
+
+	// Two classes get a single score whose sign is the class, as in the
+	// original micrograd demo. More classes get one score each.
+	outputs := data.Classes
+	if outputs == 2 {
+		outputs = 1
+	}
+	mlp := micrograd.NewMLP(2, append(layers, outputs), rng)
+	signs := data.Signs()
+
+	scores := func(x, y float64) []*micrograd.Value {
+		return mlp.Call([]*micrograd.Value{micrograd.NewValue(x), micrograd.NewValue(y)})
+	}
+	predict := func(s []*micrograd.Value) int {
+		if len(s) == 1 {
+			if s[0].Data() > 0 {
+				return 1
+			}
+			return 0
+		}
+		best := 0
+		for i, v := range s {
+			if v.Data() > s[best].Data() {
+				best = i
+			}
+		}
+		return best
+	}
+
+	for step := 0; step < *steps; step++ {
+		var losses []*micrograd.Value
+		correct := 0
+		for i, p := range data.X {
+			s := scores(p[0], p[1])
+			if predict(s) == data.Y[i] {
+				correct++
+			}
+			if len(s) == 1 {
+				losses = append(losses, micrograd.Hinge(s, signs[i:i+1]))
+			} else {
+				losses = append(losses, micrograd.MultiHinge(s, data.Y[i]))
+			}
+		}
+
+		dataLoss := micrograd.Mean(losses)
+		loss := dataLoss.Add(micrograd.L2(mlp.Parameters(), *alpha))
+
+		micrograd.ZeroGrad(mlp)
+		loss.Backward()
+
+		rate := *lr * (1 - 0.9*float64(step)/float64(*steps))
+		for _, p := range mlp.Parameters() {
+			p.SetData(p.Data() - rate*p.Grad())
+		}
+
+		fmt.Printf("step %3d | loss %.4f | accuracy %5.1f%%\n", step, loss.Data(), 100*float64(correct)/float64(data.Len()))
+	}
+
+	b := &plot.Boundary{
+		Title:  fmt.Sprintf("%s, %d steps", *name, *steps),
+		Points: data.X,
+		Labels: data.Y,
+		Classify: func(x, y float64) (class int) {
+			micrograd.NoGrad(func() { class = predict(scores(x, y)) })
+			return class
+		},
+		Cell: *cell,
+	}
+	if err := b.SavePNG(*out); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s\n", *out)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-graphviz"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// tanh shows how ops outside the engine plug in through micrograd.Function.
+type tanh struct{}
+
+func (tanh) Name() string { return "tanh" }
+
+func (tanh) Forward(in []float64) float64 { return math.Tanh(in[0]) }
+
+func (tanh) Backward(_ []float64, out, gradOut float64) []float64 {
+	return []float64{(1 - out*out) * gradOut}
+}
+
+// runGraph prints and draws the graph of a single tanh neuron after
+// Backward, the example from the micrograd lecture.
+func runGraph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	out := fs.String("out", "graph.png", "output file: PNG, graphviz DOT source for .dot or JSON for .json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	x1, x2 := micrograd.NewValue(2).SetLabel("x1"), micrograd.NewValue(0).SetLabel("x2")
+	w1, w2 := micrograd.NewValue(-3).SetLabel("w1"), micrograd.NewValue(1).SetLabel("w2")
+	b := micrograd.NewValue(6.8813735870195432).SetLabel("b")
+	n := x1.Multiply(w1).Add(x2.Multiply(w2)).Add(b).SetLabel("n")
+	o := micrograd.Apply(tanh{}, n).SetLabel("o")
+	o.Backward()
+	fmt.Println(micrograd.Expr(o))
+
+	if strings.HasSuffix(*out, ".json") {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		if err := micrograd.WriteGraph(f, o); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", *out)
+		return nil
+	}
+
+	var dot bytes.Buffer
+	if err := micrograd.WriteDot(&dot, o); err != nil {
+		return err
+	}
+	if strings.HasSuffix(*out, ".dot") {
+		if err := os.WriteFile(*out, dot.Bytes(), 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", *out)
+		return nil
+	}
+
+	ctx := context.Background()
+	g, err := graphviz.New(ctx)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	graph, err := graphviz.ParseBytes(dot.Bytes())
+	if err != nil {
+		return err
+	}
+	defer graph.Close()
+
+	if err := g.RenderFilename(ctx, graph, graphviz.PNG, *out); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s\n", *out)
+	return nil
+}
@@ -1,40 +1,68 @@
 package main
 
 import (
-	"bytes"
-	"context"
-
-	"github.com/goccy/go-graphviz"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
 )
 
-func main() {
-	ctx := context.Background()
-	g, err := graphviz.New(ctx)
-	if err != nil {
-		panic(err)
-	}
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	graph, err := g.Graph()
-	if err != nil {
-		panic(err)
-	}
+var commands = []command{
+	{"train", "train a model from a YAML config", runTrain},
+	{"sample", "generate text from a checkpoint", runSample},
+	{"serve", "serve a checkpoint over an OpenAI compatible API", runServe},
+	{"perplexity", "compare perplexity of quantized weight formats against float weights", runPerplexity},
+	{"plot", "chart loss, learning rate and gradient norm from a metrics file", runPlot},
+	{"classify", "train a micrograd MLP on a 2D toy dataset and draw its decision boundary", runClassify},
+	{"graph", "draw the autograd graph of a tanh neuron with graphviz", runGraph},
+}
 
-	// create your graph
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: nanollm <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'nanollm <command> -h' for command flags.")
+}
 
-	// 1. write encoded PNG data to buffer
-	var buf bytes.Buffer
-	if err := g.Render(ctx, graph, graphviz.PNG, &buf); err != nil {
-		panic(err)
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
 	}
 
-	// 2. get as image.Image instance
-	_, err = g.RenderImage(ctx, graph)
-	if err != nil {
-		panic(err)
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
 	}
 
-	// 3. write to file directly
-	if err := g.RenderFilename(ctx, graph, graphviz.PNG, "./graph.png"); err != nil {
-		panic(err)
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+
+		err := c.run(os.Args[2:])
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "nanollm %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
 	}
+
+	fmt.Fprintf(os.Stderr, "nanollm: unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
 }
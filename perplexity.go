@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Grimkey/nanollm/src/checkpoint"
+	"github.com/Grimkey/nanollm/src/quant"
+)
+
+func runPerplexity(args []string) error {
+	fs := flag.NewFlagSet("perplexity", flag.ContinueOnError)
+	ckptPath := fs.String("checkpoint", "checkpoints/best.json", "checkpoint file")
+	data := fs.String("data", "", "text file to measure perplexity on")
+	formats := fs.String("formats", "int8,q8_0,q4_0", "comma separated weight formats to compare: int8, q8_0, q4_0")
+	maxTokens := fs.Int("max-tokens", 0, "only use the first n tokens of the data, 0 uses all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *data == "" {
+		return fmt.Errorf("-data is required")
+	}
+
+	var fmts []quant.Format
+	for _, name := range strings.Split(*formats, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		f, err := quant.ParseFormat(name)
+		if err != nil {
+			return err
+		}
+		fmts = append(fmts, f)
+	}
+
+	m, tok, err := checkpoint.LoadModel(*ckptPath)
+	if err != nil {
+		return err
+	}
+	text, err := os.ReadFile(*data)
+	if err != nil {
+		return err
+	}
+	tokens := tok.Encode(string(text))
+	if *maxTokens > 0 && len(tokens) > *maxTokens {
+		tokens = tokens[:*maxTokens]
+	}
+
+	// Every format is measured against the unquantized weights
+	fmt.Printf("%-8s %12s %12s %10s\n", "format", "weights", "perplexity", "change")
+	var base float64
+	for _, f := range append([]quant.Format{quant.Float64}, fmts...) {
+		q, err := quant.QuantizeModel(m, f)
+		if err != nil {
+			return err
+		}
+		ppl, err := q.Perplexity(tokens)
+		if err != nil {
+			return err
+		}
+
+		change := "-"
+		if f == quant.Float64 {
+			base = ppl
+		} else {
+			change = fmt.Sprintf("%+.2f%%", 100*(ppl-base)/base)
+		}
+		fmt.Printf("%-8s %10.1fKiB %12.4f %10s\n", f, float64(q.WeightBytes())/1024, ppl, change)
+	}
+
+	return nil
+}
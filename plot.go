@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Grimkey/nanollm/src/plot"
+)
+
+func runPlot(args []string) error {
+	fs := flag.NewFlagSet("plot", flag.ContinueOnError)
+	metricsPath := fs.String("metrics", "checkpoints/metrics.jsonl", "metrics file written by nanollm train (JSONL or CSV)")
+	outDir := fs.String("out", "", "directory for the PNG charts (defaults to the metrics file's directory)")
+	logY := fs.Bool("log-y", false, "plot the loss and gradient norm on a log scale")
+	smoothing := fs.Float64("smooth", 0.6, "exponential moving average weight in [0, 1), 0 disables smoothing")
+	width := fs.Int("width", 800, "chart width in pixels")
+	height := fs.Int("height", 500, "chart height in pixels")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *smoothing < 0 || *smoothing >= 1 {
+		return fmt.Errorf("-smooth must be in [0, 1), got %g", *smoothing)
+	}
+
+	metrics, err := plot.ReadMetricsFile(*metricsPath)
+	if err != nil {
+		return err
+	}
+
+	dir := *outDir
+	if dir == "" {
+		dir = filepath.Dir(*metricsPath)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	written := 0
+	for _, spec := range plot.TrainingCharts {
+		chart := metrics.Chart(spec)
+		if chart == nil {
+			continue
+		}
+		chart.Width, chart.Height = *width, *height
+		chart.Smoothing = *smoothing
+		// The learning rate schedule is exact, smoothing would only distort it
+		if spec.File == "lr.png" {
+			chart.Smoothing = 0
+		} else {
+			chart.LogY = *logY
+		}
+
+		path := filepath.Join(dir, spec.File)
+		if err := chart.SavePNG(path); err != nil {
+			return err
+		}
+		fmt.Println("wrote", path)
+		written++
+	}
+
+	if written == 0 {
+		return fmt.Errorf("%s has no loss, lr or grad_norm values", *metricsPath)
+	}
+
+	return nil
+}
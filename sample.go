@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/Grimkey/nanollm/src/checkpoint"
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/sample"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+func runSample(args []string) error {
+	defaults := sample.DefaultOptions()
+
+	fs := flag.NewFlagSet("sample", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: nanollm sample [flags] [prompt]")
+		fmt.Fprintln(fs.Output(), "The prompt is read from -prompt-file or stdin when it is not given, or when it is \"-\".")
+		fs.PrintDefaults()
+	}
+	ckptPath := fs.String("checkpoint", "checkpoints/best.json", "checkpoint file")
+	promptFile := fs.String("prompt-file", "", "read the prompt from a file")
+	maxTokens := fs.Int("max-tokens", defaults.MaxTokens, "tokens to generate per sample")
+	temperature := fs.Float64("temperature", defaults.Temperature, "sampling temperature, 0 is greedy")
+	topK := fs.Int("top-k", defaults.TopK, "sample from the k most likely tokens, 0 disables")
+	topP := fs.Float64("top-p", defaults.TopP, "sample from the smallest set with this probability mass, 0 disables")
+	numSamples := fs.Int("num-samples", 1, "number of independent samples")
+	seed := fs.Int64("seed", 0, "random seed, 0 picks one from the clock")
+	interactive := fs.Bool("interactive", false, "chat in a REPL that keeps the conversation as context")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts := sample.Options{MaxTokens: *maxTokens, Temperature: *temperature, TopK: *topK, TopP: *topP}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	m, tok, err := checkpoint.LoadModel(*ckptPath)
+	if err != nil {
+		return err
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(*seed))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *interactive {
+		return repl(ctx, m, tok, opts, rng, os.Stdin, os.Stdout)
+	}
+
+	prompt, err := readPrompt(fs.Args(), *promptFile, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	// Ctrl-C ends the current sample and skips the rest
+	for i := 0; i < *numSamples && ctx.Err() == nil; i++ {
+		if i > 0 {
+			fmt.Println("\n---")
+		}
+		fmt.Print(prompt)
+		if _, err := stream(ctx, m, tok, tok.Encode(prompt), opts, rng, os.Stdout); err != nil {
+			return err
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
+
+// readPrompt takes the prompt from the positional arguments, a file, or
+// stdin, in that order. Stdin is only read when it is not a terminal or when
+// the prompt is "-".
+func readPrompt(args []string, file string, stdin *os.File) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		return string(data), err
+	}
+
+	info, err := stdin.Stat()
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 && info.Mode()&os.ModeCharDevice != 0 {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	return string(data), err
+}
+
+// stream generates from prompt and writes text to w as tokens arrive.
+func stream(ctx context.Context, m *model.Model, tok tokenizer.Tokenizer, prompt []int, opts sample.Options, rng *rand.Rand, w io.Writer) (string, error) {
+	s := sample.NewStream(tok)
+	_, err := sample.Generate(ctx, m, prompt, opts, rng, func(id int) error {
+		_, err := io.WriteString(w, s.Push(id))
+		return err
+	})
+	if ctx.Err() != nil {
+		err = nil
+	}
+
+	return s.Text(), err
+}
+
+// repl keeps the whole conversation as the prompt for each reply, so the
+// model always sees the most recent turns.
+func repl(ctx context.Context, m *model.Model, tok tokenizer.Tokenizer, opts sample.Options, rng *rand.Rand, in io.Reader, out io.Writer) error {
+	fmt.Fprintln(out, "Type a message and press enter. /reset clears the conversation, /quit exits.")
+
+	var history strings.Builder
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case "/quit", "/exit":
+			return nil
+		case "/reset":
+			history.Reset()
+			continue
+		}
+
+		history.WriteString(line)
+		history.WriteString("\n")
+
+		reply, err := stream(ctx, m, tok, tok.Encode(history.String()), opts, rng, out)
+		if err != nil {
+			return err
+		}
+		history.WriteString(reply)
+		history.WriteString("\n")
+		fmt.Fprintln(out)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Grimkey/nanollm/src/checkpoint"
+	"github.com/Grimkey/nanollm/src/serve"
+)
+
+func runServe(args []string) error {
+	defaults := serve.DefaultConfig()
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	ckptPath := fs.String("checkpoint", "checkpoints/best.json", "checkpoint file")
+	addr := fs.String("addr", "127.0.0.1:8080", "listen address")
+	modelID := fs.String("model-name", defaults.ModelID, "model id reported by /v1/models and expected in requests")
+	maxConcurrent := fs.Int("max-concurrent", defaults.MaxConcurrent, "generations running at once")
+	maxQueue := fs.Int("max-queue", defaults.MaxQueue, "requests allowed to wait for a free slot")
+	maxTokens := fs.Int("max-tokens", defaults.MaxTokens, "largest max_tokens a request may ask for")
+	defaultMaxTokens := fs.Int("default-max-tokens", defaults.DefaultMaxTokens, "max_tokens when a request does not set it")
+	maxN := fs.Int("max-n", defaults.MaxN, "largest number of choices per request")
+	maxPrompts := fs.Int("max-prompts", defaults.MaxPrompts, "most prompts per completion request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, tok, err := checkpoint.LoadModel(*ckptPath)
+	if err != nil {
+		return err
+	}
+
+	srv := serve.New(m, tok, serve.Config{
+		ModelID:          *modelID,
+		MaxConcurrent:    *maxConcurrent,
+		MaxQueue:         *maxQueue,
+		MaxTokens:        *maxTokens,
+		DefaultMaxTokens: *defaultMaxTokens,
+		MaxN:             *maxN,
+		MaxPrompts:       *maxPrompts,
+	})
+	httpServer := &http.Server{Addr: *addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		fmt.Printf("serving %s on http://%s/v1\n", *modelID, *addr)
+		errc <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package autodiff
+
+import "github.com/Grimkey/nanollm/src/micrograd"
+
+// Func is a scalar function written with Value ops, e.g. for x² + y:
+//
+//	func(x []*micrograd.Value) *micrograd.Value { return x[0].Multiply(x[0]).Add(x[1]) }
+//
+// The transformations below call it on fresh leaves each time, so it must
+// not keep Values between calls.
+type Func func(x []*micrograd.Value) *micrograd.Value
+
+// VecFunc is a vector valued Func.
+type VecFunc func(x []*micrograd.Value) []*micrograd.Value
+
+// Grad returns the function computing the gradient of f.
+func Grad(f Func) func(x []float64) []float64 {
+	vg := ValueAndGrad(f)
+	return func(x []float64) []float64 {
+		_, grad := vg(x)
+		return grad
+	}
+}
+
+// ValueAndGrad returns the function computing f and its gradient in one
+// pass.
+func ValueAndGrad(f Func) func(x []float64) (float64, []float64) {
+	return func(x []float64) (float64, []float64) {
+		in := leaves(x)
+		out := f(in)
+		return out.Data(), data(micrograd.Grad(out, in, false))
+	}
+}
+
+// Jacobian returns the function computing the Jacobian of f, one row per
+// output. It runs one reverse pass per output, so it suits functions with
+// few outputs.
+func Jacobian(f VecFunc) func(x []float64) [][]float64 {
+	return func(x []float64) [][]float64 {
+		in := leaves(x)
+		outs := f(in)
+
+		jac := make([][]float64, len(outs))
+		for i, out := range outs {
+			jac[i] = data(micrograd.Grad(out, in, false))
+		}
+
+		return jac
+	}
+}
+
+// Hessian returns the function computing the matrix of second derivatives
+// of f by differentiating each component of its gradient graph.
+func Hessian(f Func) func(x []float64) [][]float64 {
+	return func(x []float64) [][]float64 {
+		in := leaves(x)
+		grads := micrograd.Grad(f(in), in, true)
+
+		hess := make([][]float64, len(grads))
+		for i, g := range grads {
+			hess[i] = data(micrograd.Grad(g, in, false))
+		}
+
+		return hess
+	}
+}
+
+// DualFunc is a vector valued function written with forward mode Dual
+// ops.
+type DualFunc func(x []micrograd.Dual) []micrograd.Dual
+
+// JVP returns the function computing f(x) and the Jacobian-vector product
+// J·v in a single forward pass.
+func JVP(f DualFunc) func(x, v []float64) (y, jv []float64) {
+	return func(x, v []float64) ([]float64, []float64) {
+		in := make([]micrograd.Dual, len(x))
+		for i := range x {
+			in[i] = micrograd.NewDual(x[i], v[i])
+		}
+
+		outs := f(in)
+		y := make([]float64, len(outs))
+		jv := make([]float64, len(outs))
+		for i, d := range outs {
+			y[i], jv[i] = d.Val, d.Tan
+		}
+
+		return y, jv
+	}
+}
+
+// JacobianForward computes the same matrix as Jacobian one column per
+// input with JVP, which is cheaper when f has fewer inputs than outputs.
+func JacobianForward(f DualFunc) func(x []float64) [][]float64 {
+	jvp := JVP(f)
+	return func(x []float64) [][]float64 {
+		var jac [][]float64
+		v := make([]float64, len(x))
+		for j := range x {
+			v[j] = 1
+			_, col := jvp(x, v)
+			v[j] = 0
+
+			if jac == nil {
+				jac = make([][]float64, len(col))
+				for i := range jac {
+					jac[i] = make([]float64, len(x))
+				}
+			}
+			for i, d := range col {
+				jac[i][j] = d
+			}
+		}
+
+		return jac
+	}
+}
+
+func leaves(x []float64) []*micrograd.Value {
+	in := make([]*micrograd.Value, len(x))
+	for i, v := range x {
+		in[i] = micrograd.NewValue(v)
+	}
+
+	return in
+}
+
+func data(values []*micrograd.Value) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = v.Data()
+	}
+
+	return out
+}
@@ -0,0 +1,155 @@
+package autodiff
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// rosenbrock is (1 - x)² + 100(y - x²)².
+func rosenbrock(v []*micrograd.Value) *micrograd.Value {
+	x, y := v[0], v[1]
+	a := x.Neg().AddScalar(1)
+	b := y.Sub(x.Multiply(x))
+	return a.Multiply(a).Add(b.Multiply(b).MulScalar(100))
+}
+
+func TestValueAndGrad(t *testing.T) {
+	x, y := -1.2, 1.0
+
+	value, grad := ValueAndGrad(rosenbrock)([]float64{x, y})
+
+	assert.InDelta(t, math.Pow(1-x, 2)+100*math.Pow(y-x*x, 2), value, 1e-9)
+	require.Len(t, grad, 2)
+	assert.InDelta(t, -2*(1-x)-400*x*(y-x*x), grad[0], 1e-9)
+	assert.InDelta(t, 200*(y-x*x), grad[1], 1e-9)
+	assert.Equal(t, grad, Grad(rosenbrock)([]float64{x, y}))
+}
+
+func TestHessian(t *testing.T) {
+	x, y := -1.2, 1.0
+
+	h := Hessian(rosenbrock)([]float64{x, y})
+
+	want := [][]float64{
+		{2 - 400*(y-x*x) + 800*x*x, -400 * x},
+		{-400 * x, 200},
+	}
+	for i := range want {
+		for j := range want[i] {
+			assert.InDelta(t, want[i][j], h[i][j], 1e-9, "H[%d][%d]", i, j)
+		}
+	}
+}
+
+// Jacobian of (x, y, z) ↦ (xy, e^y + z²) should be one row of partial
+// derivatives per output, matching the analytic values.
+func TestJacobian(t *testing.T) {
+	f := func(v []*micrograd.Value) []*micrograd.Value {
+		x, y, z := v[0], v[1], v[2]
+		return []*micrograd.Value{
+			x.Multiply(y),
+			y.Exp().Add(z.PowScalar(2)),
+		}
+	}
+
+	jac := Jacobian(f)([]float64{2, 0.5, -3})
+
+	want := [][]float64{
+		{0.5, 2, 0},
+		{0, math.Exp(0.5), -6},
+	}
+	require.Len(t, jac, 2)
+	for i := range want {
+		assert.InDeltaSlice(t, want[i], jac[i], 1e-9)
+	}
+}
+
+func TestGradDescent(t *testing.T) {
+	grad := Grad(func(v []*micrograd.Value) *micrograd.Value {
+		a := v[0].AddScalar(-3)
+		b := v[1].AddScalar(2)
+		return a.Multiply(a).Add(b.Multiply(b))
+	})
+
+	x := []float64{0, 0}
+	for i := 0; i < 100; i++ {
+		g := grad(x)
+		for j := range x {
+			x[j] -= 0.1 * g[j]
+		}
+	}
+
+	assert.InDeltaSlice(t, []float64{3, -2}, x, 1e-6)
+}
+
+// Forward mode JacobianForward should agree with reverse mode Jacobian on a
+// function with one input and many outputs, t ↦ (t², e^t, t·ln t, 1/t).
+func TestJacobianForwardMatchesReverse(t *testing.T) {
+	reverse := Jacobian(func(v []*micrograd.Value) []*micrograd.Value {
+		x := v[0]
+		return []*micrograd.Value{x.Multiply(x), x.Exp(), x.Multiply(x.Log()), micrograd.NewValue(1).Div(x)}
+	})
+	forward := JacobianForward(func(v []micrograd.Dual) []micrograd.Dual {
+		x := v[0]
+		return []micrograd.Dual{x.Multiply(x), x.Exp(), x.Multiply(x.Log()), micrograd.Constant(1).Div(x)}
+	})
+
+	x := []float64{1.7}
+	want := reverse(x)
+	got := forward(x)
+	require.Len(t, got, 4)
+	for i := range want {
+		assert.InDeltaSlice(t, want[i], got[i], 1e-9)
+	}
+	assert.InDelta(t, math.Log(1.7)+1, got[2][0], 1e-9)
+}
+
+func TestJVP(t *testing.T) {
+	f := func(v []micrograd.Dual) []micrograd.Dual {
+		return []micrograd.Dual{v[0].Multiply(v[1]), v[0].Add(v[1].MulScalar(3))}
+	}
+
+	y, jv := JVP(f)([]float64{2, 5}, []float64{1, -1})
+
+	assert.Equal(t, []float64{10, 17}, y)
+	assert.InDeltaSlice(t, []float64{5 - 2, 1 - 3}, jv, 1e-9)
+}
+
+// probe is the identity. Its backward pass, float or graph, records whether
+// graph recording was on while it ran.
+type probe struct{ enabled *[]bool }
+
+func (p probe) Forward(inputs []float64) float64 { return inputs[0] }
+
+func (p probe) Backward(inputs []float64, out, gradOut float64) []float64 {
+	*p.enabled = append(*p.enabled, micrograd.GradEnabled())
+	return []float64{gradOut}
+}
+
+func (p probe) BackwardGraph(inputs []*micrograd.Value, out, gradOut *micrograd.Value) []*micrograd.Value {
+	return []*micrograd.Value{micrograd.Apply(p, gradOut)}
+}
+
+// Jacobian and Hessian take their float gradients without the process-wide
+// NoGrad switch, so graphs built meanwhile on other goroutines still
+// record.
+func TestJacobianAndHessianLeaveRecordingOn(t *testing.T) {
+	var enabled []bool
+	p := probe{&enabled}
+	f := func(v []*micrograd.Value) *micrograd.Value {
+		return micrograd.Apply(p, v[0]).Multiply(v[0])
+	}
+
+	jac := Jacobian(func(v []*micrograd.Value) []*micrograd.Value { return []*micrograd.Value{f(v)} })([]float64{3})
+	assert.Equal(t, [][]float64{{6}}, jac)
+	h := Hessian(f)([]float64{3})
+	assert.Equal(t, [][]float64{{2}}, h)
+
+	require.NotEmpty(t, enabled)
+	assert.NotContains(t, enabled, false)
+}
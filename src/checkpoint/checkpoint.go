@@ -0,0 +1,133 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+// Checkpoint bundles everything needed to rebuild a trained model: its
+// configuration, tokenizer and weights.
+type Checkpoint struct {
+	Step      int            `json:"step"`
+	ValLoss   *float64       `json:"val_loss,omitempty"`
+	Model     model.Config   `json:"model"`
+	Tokenizer tokenizer.Spec `json:"tokenizer"`
+	Params    []Tensor       `json:"params"`
+}
+
+type Tensor struct {
+	Name  string    `json:"name"`
+	Shape []int     `json:"shape"`
+	Data  []float64 `json:"data"`
+}
+
+// New snapshots the model weights. A NaN valLoss, meaning the run had no
+// validation split, is left out of the checkpoint.
+func New(m *model.Model, tok tokenizer.Tokenizer, step int, valLoss float64) *Checkpoint {
+	named := m.NamedParameters()
+	params := make([]Tensor, len(named))
+	for i, p := range named {
+		data := make([]float64, len(p.Values))
+		for j, v := range p.Values {
+			data[j] = v.Data()
+		}
+		params[i] = Tensor{Name: p.Name, Shape: p.Shape, Data: data}
+	}
+
+	c := &Checkpoint{
+		Step:      step,
+		Model:     m.Config,
+		Tokenizer: tok.Spec(),
+		Params:    params,
+	}
+	if !math.IsNaN(valLoss) {
+		c.ValLoss = &valLoss
+	}
+
+	return c
+}
+
+// Restore rebuilds the model and tokenizer stored in the checkpoint.
+func (c *Checkpoint) Restore() (*model.Model, tokenizer.Tokenizer, error) {
+	tok, err := tokenizer.FromSpec(c.Tokenizer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Weights are overwritten below, the seed only has to produce a model
+	m, err := model.New(c.Model, rand.New(rand.NewSource(0)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored := make(map[string]Tensor, len(c.Params))
+	for _, t := range c.Params {
+		stored[t.Name] = t
+	}
+
+	for _, p := range m.NamedParameters() {
+		t, ok := stored[p.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("checkpoint is missing parameter %q", p.Name)
+		}
+		if len(t.Data) != len(p.Values) {
+			return nil, nil, fmt.Errorf("parameter %q has %d values, model expects %d", p.Name, len(t.Data), len(p.Values))
+		}
+		for i, v := range p.Values {
+			v.SetData(t.Data[i])
+		}
+	}
+
+	return m, tok, nil
+}
+
+// Save writes the checkpoint as JSON. The file is written to a temporary
+// name first so an interrupted run never leaves a truncated checkpoint.
+func Save(path string, c *Checkpoint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func Load(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// LoadModel loads a checkpoint file and restores its model and tokenizer.
+func LoadModel(path string) (*model.Model, tokenizer.Tokenizer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.Restore()
+}
@@ -0,0 +1,64 @@
+package checkpoint
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	tok := tokenizer.NewChar("abcde")
+	m, err := model.New(model.Config{VocabSize: tok.VocabSize(), ContextSize: 2, EmbedDim: 3, Hidden: []int{4}}, rand.New(rand.NewSource(7)))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "nested", "ckpt.json")
+	require.NoError(t, Save(path, New(m, tok, 12, 1.5)))
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 12, c.Step)
+	require.NotNil(t, c.ValLoss)
+	assert.Equal(t, 1.5, *c.ValLoss)
+
+	restored, restoredTok, err := c.Restore()
+	require.NoError(t, err)
+	assert.Equal(t, tok.Encode("cab"), restoredTok.Encode("cab"))
+
+	want := m.Forward([]int{1, 2})
+	got := restored.Forward([]int{1, 2})
+	for i := range want {
+		assert.Equal(t, want[i].Data(), got[i].Data(), "Restored model should produce identical logits")
+	}
+}
+
+func TestSaveWithoutValLoss(t *testing.T) {
+	tok := tokenizer.NewChar("ab")
+	m, err := model.New(model.Config{VocabSize: 2, ContextSize: 1, EmbedDim: 1}, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ckpt.json")
+	require.NoError(t, Save(path, New(m, tok, 1, math.NaN())))
+
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, c.ValLoss, "A NaN validation loss should be omitted")
+}
+
+func TestRestoreRejectsMismatchedShapes(t *testing.T) {
+	tok := tokenizer.NewChar("abc")
+	m, err := model.New(model.Config{VocabSize: 3, ContextSize: 1, EmbedDim: 2}, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	c := New(m, tok, 0, 0)
+	c.Params[0].Data = c.Params[0].Data[:1]
+
+	_, _, err = c.Restore()
+	assert.Error(t, err)
+}
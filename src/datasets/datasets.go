@@ -0,0 +1,195 @@
+package datasets
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Dataset is a set of 2D points with integer class labels in [0, Classes).
+// Generators take their own *rand.Rand, so the same seed always produces
+// the same points, noise included.
+type Dataset struct {
+	X       [][2]float64
+	Y       []int
+	Classes int
+}
+
+func (d *Dataset) Len() int {
+	return len(d.X)
+}
+
+// Signs maps the labels of a two class dataset to -1 and +1, the targets a
+// hinge loss expects.
+func (d *Dataset) Signs() []float64 {
+	signs := make([]float64, len(d.Y))
+	for i, y := range d.Y {
+		signs[i] = float64(2*y - 1)
+	}
+
+	return signs
+}
+
+// Bounds returns the smallest box holding every point.
+func (d *Dataset) Bounds() (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, p := range d.X {
+		xmin, xmax = math.Min(xmin, p[0]), math.Max(xmax, p[0])
+		ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+	}
+
+	return xmin, xmax, ymin, ymax
+}
+
+func (d *Dataset) add(x, y float64, label int) {
+	d.X = append(d.X, [2]float64{x, y})
+	d.Y = append(d.Y, label)
+}
+
+// jitter adds gaussian noise with standard deviation noise to every point.
+func (d *Dataset) jitter(noise float64, rng *rand.Rand) {
+	for i := range d.X {
+		d.X[i][0] += rng.NormFloat64() * noise
+		d.X[i][1] += rng.NormFloat64() * noise
+	}
+}
+
+// shuffle interleaves the classes, which generators produce in blocks.
+func (d *Dataset) shuffle(rng *rand.Rand) {
+	rng.Shuffle(len(d.X), func(i, j int) {
+		d.X[i], d.X[j] = d.X[j], d.X[i]
+		d.Y[i], d.Y[j] = d.Y[j], d.Y[i]
+	})
+}
+
+// Moons is two interleaving half circles, class 0 on top.
+func Moons(n int, noise float64, rng *rand.Rand) *Dataset {
+	d := &Dataset{Classes: 2}
+	outer := n / 2
+	for i := 0; i < outer; i++ {
+		t := math.Pi * float64(i) / float64(max(outer-1, 1))
+		d.add(math.Cos(t), math.Sin(t), 0)
+	}
+	inner := n - outer
+	for i := 0; i < inner; i++ {
+		t := math.Pi * float64(i) / float64(max(inner-1, 1))
+		d.add(1-math.Cos(t), 0.5-math.Sin(t), 1)
+	}
+
+	d.jitter(noise, rng)
+	d.shuffle(rng)
+	return d
+}
+
+// Circles is a unit circle (class 0) around a smaller one of radius factor
+// (class 1).
+func Circles(n int, noise, factor float64, rng *rand.Rand) *Dataset {
+	d := &Dataset{Classes: 2}
+	outer := n / 2
+	for i := 0; i < outer; i++ {
+		t := 2 * math.Pi * float64(i) / float64(outer)
+		d.add(math.Cos(t), math.Sin(t), 0)
+	}
+	inner := n - outer
+	for i := 0; i < inner; i++ {
+		t := 2 * math.Pi * float64(i) / float64(inner)
+		d.add(factor*math.Cos(t), factor*math.Sin(t), 1)
+	}
+
+	d.jitter(noise, rng)
+	d.shuffle(rng)
+	return d
+}
+
+// Spirals is the CS231n spiral: classes arms winding out from the origin,
+// each turning through 4 radians. The noise is applied to the angle, so it
+// grows with the radius.
+func Spirals(n, classes int, noise float64, rng *rand.Rand) *Dataset {
+	d := &Dataset{Classes: classes}
+	for c := 0; c < classes; c++ {
+		count := n / classes
+		if c < n%classes {
+			count++
+		}
+		for i := 0; i < count; i++ {
+			r := float64(i) / float64(max(count-1, 1))
+			t := 4*(float64(c)+r) + rng.NormFloat64()*noise
+			d.add(r*math.Sin(t), r*math.Cos(t), c)
+		}
+	}
+
+	d.shuffle(rng)
+	return d
+}
+
+// Blobs draws gaussian clusters with standard deviation std around centers
+// placed uniformly in [-10, 10] on both axes.
+func Blobs(n, centers int, std float64, rng *rand.Rand) *Dataset {
+	d := &Dataset{Classes: centers}
+	means := make([][2]float64, centers)
+	for c := range means {
+		means[c] = [2]float64{rng.Float64()*20 - 10, rng.Float64()*20 - 10}
+	}
+
+	for i := 0; i < n; i++ {
+		c := i % centers
+		d.add(means[c][0]+rng.NormFloat64()*std, means[c][1]+rng.NormFloat64()*std, c)
+	}
+
+	d.shuffle(rng)
+	return d
+}
+
+// XOR samples points uniformly in [-1, 1]² labelled 1 when exactly one
+// coordinate is positive. Noise moves the points after labelling, so noisy
+// points can cross the axes.
+func XOR(n int, noise float64, rng *rand.Rand) *Dataset {
+	d := &Dataset{Classes: 2}
+	for i := 0; i < n; i++ {
+		x, y := rng.Float64()*2-1, rng.Float64()*2-1
+		label := 0
+		if (x > 0) != (y > 0) {
+			label = 1
+		}
+		d.add(x, y, label)
+	}
+
+	d.jitter(noise, rng)
+	return d
+}
+
+var generators = map[string]func(n int, noise float64, rng *rand.Rand) *Dataset{
+	"moons":   Moons,
+	"circles": func(n int, noise float64, rng *rand.Rand) *Dataset { return Circles(n, noise, 0.5, rng) },
+	"spirals": func(n int, noise float64, rng *rand.Rand) *Dataset { return Spirals(n, 3, noise, rng) },
+	"blobs":   func(n int, noise float64, rng *rand.Rand) *Dataset { return Blobs(n, 3, 1+noise, rng) },
+	"xor":     XOR,
+}
+
+// Names lists the datasets Generate knows.
+func Names() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Generate builds a dataset by name with default shape parameters: circles
+// with an inner radius of 0.5, three spirals, and three blobs whose spread
+// is 1 + noise.
+func Generate(name string, n int, noise float64, rng *rand.Rand) (*Dataset, error) {
+	gen, ok := generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dataset %q, expected one of %v", name, Names())
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("dataset size must be positive, got %d", n)
+	}
+
+	return gen(n, noise, rng), nil
+}
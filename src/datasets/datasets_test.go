@@ -0,0 +1,84 @@
+package datasets
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateShapes(t *testing.T) {
+	for _, name := range Names() {
+		d, err := Generate(name, 101, 0.1, rand.New(rand.NewSource(1)))
+		require.NoError(t, err, name)
+
+		assert.Equal(t, 101, d.Len(), name)
+		assert.Len(t, d.Y, 101, name)
+
+		counts := make([]int, d.Classes)
+		for _, y := range d.Y {
+			require.True(t, y >= 0 && y < d.Classes, "%s: label %d out of range", name, y)
+			counts[y]++
+		}
+		for c, count := range counts {
+			assert.Positive(t, count, "%s: class %d is empty", name, c)
+		}
+	}
+}
+
+func TestGenerateIsSeeded(t *testing.T) {
+	for _, name := range Names() {
+		a, err := Generate(name, 50, 0.2, rand.New(rand.NewSource(7)))
+		require.NoError(t, err)
+		b, err := Generate(name, 50, 0.2, rand.New(rand.NewSource(7)))
+		require.NoError(t, err)
+		c, err := Generate(name, 50, 0.2, rand.New(rand.NewSource(8)))
+		require.NoError(t, err)
+
+		assert.Equal(t, a, b, "%s: same seed should give the same data", name)
+		assert.NotEqual(t, a.X, c.X, "%s: different seeds should give different noise", name)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate("swiss roll", 10, 0, rand.New(rand.NewSource(1)))
+	assert.ErrorContains(t, err, "moons")
+
+	_, err = Generate("moons", 0, 0, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
+
+func TestNoiselessGeometry(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	moons := Moons(40, 0, rng)
+	for i, p := range moons.X {
+		// Each moon is a unit half circle, centred on (0, 0) and (1, 0.5)
+		cx, cy := 0.0, 0.0
+		if moons.Y[i] == 1 {
+			cx, cy = 1, 0.5
+		}
+		assert.InDelta(t, 1.0, math.Hypot(p[0]-cx, p[1]-cy), 1e-9)
+	}
+
+	circles := Circles(40, 0, 0.3, rng)
+	for i, p := range circles.X {
+		radius := 1.0
+		if circles.Y[i] == 1 {
+			radius = 0.3
+		}
+		assert.InDelta(t, radius, math.Hypot(p[0], p[1]), 1e-9)
+	}
+
+	xor := XOR(200, 0, rng)
+	for i, p := range xor.X {
+		assert.Equal(t, (p[0] > 0) != (p[1] > 0), xor.Y[i] == 1)
+	}
+}
+
+func TestSigns(t *testing.T) {
+	d := &Dataset{Y: []int{0, 1, 1}, Classes: 2}
+	assert.Equal(t, []float64{-1, 1, 1}, d.Signs())
+}
@@ -0,0 +1,68 @@
+package dtype
+
+// Buffer holds values stored in a DType: float64 and float32 as
+// themselves, float16 and bfloat16 as their 16 bit patterns, so a buffer
+// takes Size bytes per value. Values go in and come out as float64.
+type Buffer struct {
+	dtype DType
+	f64   []float64
+	f32   []float32
+	f16   []F16
+	bf16  []BF16
+}
+
+// NewBuffer returns a buffer of n zeros stored as d.
+func NewBuffer(d DType, n int) *Buffer {
+	b := &Buffer{dtype: d}
+	switch d {
+	case Float32:
+		b.f32 = make([]float32, n)
+	case Float16:
+		b.f16 = make([]F16, n)
+	case BFloat16:
+		b.bf16 = make([]BF16, n)
+	default:
+		b.f64 = make([]float64, n)
+	}
+
+	return b
+}
+
+func (b *Buffer) DType() DType { return b.dtype }
+
+func (b *Buffer) Len() int {
+	return len(b.f64) + len(b.f32) + len(b.f16) + len(b.bf16)
+}
+
+// Bytes is the memory the values take.
+func (b *Buffer) Bytes() int {
+	return b.Len() * b.dtype.Size()
+}
+
+// Store rounds src into the buffer, writing min(Len(), len(src)) values.
+func (b *Buffer) Store(src []float64) {
+	switch b.dtype {
+	case Float32:
+		ToFloat32(b.f32, src)
+	case Float16:
+		ToF16(b.f16, src)
+	case BFloat16:
+		ToBF16(b.bf16, src)
+	default:
+		copy(b.f64, src)
+	}
+}
+
+// Load writes the stored values to dst, min(Len(), len(dst)) of them.
+func (b *Buffer) Load(dst []float64) {
+	switch b.dtype {
+	case Float32:
+		FromFloat32(dst, b.f32)
+	case Float16:
+		FromF16(dst, b.f16)
+	case BFloat16:
+		FromBF16(dst, b.bf16)
+	default:
+		copy(dst, b.f64)
+	}
+}
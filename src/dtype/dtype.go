@@ -0,0 +1,132 @@
+package dtype
+
+import (
+	"fmt"
+	"math"
+)
+
+// DType is a floating point storage format. Arithmetic is done in float64;
+// a DType decides how values are rounded when they are stored.
+type DType uint8
+
+const (
+	Float64 DType = iota
+	Float32
+	// Float16 is IEEE 754 half precision: 10 mantissa bits and a largest
+	// finite value of 65504, so small gradients underflow and large ones
+	// overflow unless the loss is scaled.
+	Float16
+	// BFloat16 keeps float32's 8 exponent bits and 7 mantissa bits. It has
+	// float32's range, so it needs no loss scaling, but only 2-3 digits.
+	BFloat16
+)
+
+var names = [...]string{Float64: "float64", Float32: "float32", Float16: "float16", BFloat16: "bfloat16"}
+
+// Parse returns the DType with the given name.
+func Parse(name string) (DType, error) {
+	for d, n := range names {
+		if n == name {
+			return DType(d), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown dtype %q, want float64, float32, float16 or bfloat16", name)
+}
+
+func (d DType) String() string {
+	if int(d) < len(names) {
+		return names[d]
+	}
+	return fmt.Sprintf("DType(%d)", d)
+}
+
+// Size is the number of bytes a value takes.
+func (d DType) Size() int {
+	switch d {
+	case Float64:
+		return 8
+	case Float32:
+		return 4
+	}
+	return 2
+}
+
+// format describes a binary format by its mantissa bits (excluding the
+// implicit one) and the exponents of its smallest normal and largest
+// finite numbers.
+type format struct {
+	mantissa   int
+	minExp     int // exponent of the smallest normal number
+	maxFinite  float64
+	smallestSN float64 // smallest subnormal
+}
+
+func newFormat(mantissa, minExp, maxExp int) format {
+	return format{
+		mantissa:   mantissa,
+		minExp:     minExp,
+		maxFinite:  math.Ldexp(2-math.Ldexp(1, -mantissa), maxExp),
+		smallestSN: math.Ldexp(1, minExp-mantissa),
+	}
+}
+
+var (
+	float16Format  = newFormat(10, -14, 15)
+	bfloat16Format = newFormat(7, -126, 127)
+)
+
+// Max is the largest finite value d can hold.
+func (d DType) Max() float64 {
+	switch d {
+	case Float32:
+		return math.MaxFloat32
+	case Float16:
+		return float16Format.maxFinite
+	case BFloat16:
+		return bfloat16Format.maxFinite
+	}
+	return math.MaxFloat64
+}
+
+// Round returns x rounded to the nearest value d can hold, ties to even.
+// Values beyond d's range become ±Inf and values below its smallest
+// subnormal become ±0, as they would when stored.
+func (d DType) Round(x float64) float64 {
+	switch d {
+	case Float32:
+		return float64(float32(x))
+	case Float16:
+		return float16Format.round(x)
+	case BFloat16:
+		return bfloat16Format.round(x)
+	}
+	return x
+}
+
+// round works in float64, where x's mantissa has more bits than the target
+// and scaling by powers of two is exact, so a single RoundToEven at the
+// target's unit in the last place is correctly rounded.
+func (f format) round(x float64) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return x
+	}
+
+	_, exp := math.Frexp(x) // |x| is in [2^(exp-1), 2^exp)
+	ulp := math.Ldexp(1, max(exp-1, f.minExp)-f.mantissa)
+	r := math.RoundToEven(x/ulp) * ulp
+	if math.Abs(r) > f.maxFinite {
+		return math.Copysign(math.Inf(1), x)
+	}
+	return r
+}
+
+// RoundSlice rounds every element of x to d in place.
+func (d DType) RoundSlice(x []float64) {
+	if d == Float64 {
+		return
+	}
+	for i, v := range x {
+		x[i] = d.Round(v)
+	}
+}
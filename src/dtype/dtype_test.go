@@ -0,0 +1,155 @@
+package dtype
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, d := range []DType{Float64, Float32, Float16, BFloat16} {
+		got, err := Parse(d.String())
+		require.NoError(t, err)
+		assert.Equal(t, d, got)
+	}
+
+	_, err := Parse("float8")
+	assert.EqualError(t, err, `unknown dtype "float8", want float64, float32, float16 or bfloat16`)
+}
+
+func TestSize(t *testing.T) {
+	assert.Equal(t, []int{8, 4, 2, 2}, []int{Float64.Size(), Float32.Size(), Float16.Size(), BFloat16.Size()})
+}
+
+func TestFloat16KnownValues(t *testing.T) {
+	cases := []struct {
+		x    float64
+		bits F16
+	}{
+		{1, 0x3c00},
+		{-2, 0xc000},
+		{0.1, 0x2e66},
+		{65504, 0x7bff},
+		{65519, 0x7bff},              // Below the midpoint to the next power of two
+		{65520, 0x7c00},              // The midpoint rounds to even, which is Inf
+		{math.Ldexp(1, -14), 0x0400}, // Smallest normal
+		{math.Ldexp(1, -24), 0x0001}, // Smallest subnormal
+		{math.Ldexp(1, -25), 0x0000}, // Halfway to zero rounds to even
+		{math.Ldexp(3, -26), 0x0001},
+		{math.Copysign(0, -1), 0x8000},
+		{math.Inf(-1), 0xfc00},
+		{1 + math.Ldexp(1, -11), 0x3c00}, // Tie between 1 and its successor goes to even
+		{1 + math.Ldexp(3, -11), 0x3c02},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.bits, NewF16(c.x), "%g", c.x)
+	}
+
+	assert.True(t, math.IsNaN(NewF16(math.NaN()).Float64()))
+	assert.Equal(t, 65504.0, Float16.Max())
+}
+
+func TestFloat16RoundTripsEveryValue(t *testing.T) {
+	for bits := 0; bits <= math.MaxUint16; bits++ {
+		h := F16(bits)
+		x := h.Float64()
+		if math.IsNaN(x) {
+			continue
+		}
+		require.Equal(t, h, NewF16(x), "bits %#04x", bits)
+		require.Equal(t, x, Float16.Round(x), "bits %#04x", bits)
+	}
+}
+
+// bfloat16Reference rounds a float32 to bfloat16 the way hardware does, by
+// adding half a unit and the tie-breaking bit before truncating.
+func bfloat16Reference(f float32) BF16 {
+	bits := math.Float32bits(f)
+	return BF16((bits + 0x7fff + (bits>>16)&1) >> 16)
+}
+
+func TestBFloat16(t *testing.T) {
+	assert.Equal(t, BF16(0x3f80), NewBF16(1))
+	assert.Equal(t, BF16(0x4049), NewBF16(math.Pi))
+	assert.Equal(t, float32(3.140625), NewBF16(math.Pi).Float32())
+	assert.Equal(t, BF16(0x7f80), NewBF16(math.MaxFloat32))
+	assert.True(t, math.IsNaN(NewBF16(math.NaN()).Float64()))
+
+	for bits := 0; bits <= math.MaxUint16; bits++ {
+		b := BF16(bits)
+		x := b.Float64()
+		if math.IsNaN(x) {
+			continue
+		}
+		require.Equal(t, b, NewBF16(x), "bits %#04x", bits)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		f := math.Float32frombits(rng.Uint32())
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			continue
+		}
+		want := bfloat16Reference(f)
+		require.Equal(t, want, NewBF16(float64(f)), "%g", f)
+		require.Equal(t, want.Float64(), BFloat16.Round(float64(f)), "%g", f)
+	}
+}
+
+func TestRound(t *testing.T) {
+	x := 1.0 / 3
+	assert.Equal(t, x, Float64.Round(x))
+	assert.Equal(t, float64(float32(x)), Float32.Round(x))
+	assert.Equal(t, 0.333251953125, Float16.Round(x))
+	assert.Equal(t, 0.333984375, BFloat16.Round(x))
+	assert.Equal(t, math.Inf(1), Float16.Round(1e5))
+	assert.Equal(t, 99840.0, BFloat16.Round(1e5), "bfloat16 has the range float16 lacks")
+
+	xs := []float64{x, 1e5, 1e-9}
+	Float16.RoundSlice(xs)
+	assert.Equal(t, []float64{0.333251953125, math.Inf(1), 0}, xs)
+}
+
+func TestSliceConversions(t *testing.T) {
+	src := []float64{1.5, -0.25, 1.0 / 3, 1e5}
+
+	f32 := make([]float32, len(src))
+	ToFloat32(f32, src)
+	got := make([]float64, len(src))
+	FromFloat32(got, f32)
+	assert.Equal(t, []float64{1.5, -0.25, float64(float32(1.0 / 3)), 1e5}, got)
+
+	f16 := make([]F16, len(src))
+	ToF16(f16, src)
+	FromF16(got, f16)
+	assert.Equal(t, []float64{1.5, -0.25, 0.333251953125, math.Inf(1)}, got)
+
+	bf16 := make([]BF16, len(src))
+	ToBF16(bf16, src)
+	FromBF16(got, bf16)
+	assert.Equal(t, []float64{1.5, -0.25, 0.333984375, 99840}, got)
+
+	// Only the common length is converted
+	short := make([]float32, 2)
+	ToFloat32(short, src)
+	assert.Equal(t, []float32{1.5, -0.25}, short)
+}
+
+func TestBuffer(t *testing.T) {
+	src := []float64{1.5, 1.0 / 3, 1e5}
+	for _, d := range []DType{Float64, Float32, Float16, BFloat16} {
+		b := NewBuffer(d, len(src))
+		assert.Equal(t, d, b.DType())
+		assert.Equal(t, len(src)*d.Size(), b.Bytes())
+
+		b.Store(src)
+		got := make([]float64, len(src))
+		b.Load(got)
+		want := append([]float64(nil), src...)
+		d.RoundSlice(want)
+		assert.Equal(t, want, got, "%v", d)
+	}
+}
@@ -0,0 +1,117 @@
+package dtype
+
+import "math"
+
+// F16 holds the bits of an IEEE 754 half precision number.
+type F16 uint16
+
+// NewF16 rounds x to the nearest half precision value, ties to even.
+func NewF16(x float64) F16 {
+	if math.IsNaN(x) {
+		return 0x7e00
+	}
+
+	var sign uint16
+	if math.Signbit(x) {
+		sign = 0x8000
+	}
+	r := math.Abs(float16Format.round(x))
+	switch {
+	case math.IsInf(r, 0):
+		return F16(sign | 0x7c00)
+	case r < math.Ldexp(1, float16Format.minExp):
+		// Subnormal: the mantissa counts multiples of the smallest one
+		return F16(sign | uint16(r/float16Format.smallestSN))
+	}
+
+	_, exp := math.Frexp(r)
+	exp--
+	mantissa := uint16(math.Ldexp(r, float16Format.mantissa-exp)) & 0x3ff
+	return F16(sign | uint16(exp+15)<<10 | mantissa)
+}
+
+func (h F16) Float64() float64 {
+	exp := int(h>>10) & 0x1f
+	mantissa := float64(h & 0x3ff)
+
+	var x float64
+	switch exp {
+	case 0:
+		x = mantissa * float16Format.smallestSN
+	case 0x1f:
+		if mantissa != 0 {
+			return math.NaN()
+		}
+		x = math.Inf(1)
+	default:
+		x = math.Ldexp(1+mantissa/1024, exp-15)
+	}
+
+	if h&0x8000 != 0 {
+		return -x
+	}
+	return x
+}
+
+func (h F16) Float32() float32 {
+	return float32(h.Float64())
+}
+
+// BF16 holds the bits of a bfloat16 number, the top half of a float32.
+type BF16 uint16
+
+// NewBF16 rounds x to the nearest bfloat16 value, ties to even.
+func NewBF16(x float64) BF16 {
+	if math.IsNaN(x) {
+		return 0x7fc0
+	}
+
+	// The rounded value is exact in float32 with the low 16 bits zero
+	return BF16(math.Float32bits(float32(bfloat16Format.round(x))) >> 16)
+}
+
+func (b BF16) Float32() float32 {
+	return math.Float32frombits(uint32(b) << 16)
+}
+
+func (b BF16) Float64() float64 {
+	return float64(b.Float32())
+}
+
+// The slice conversions below write min(len(dst), len(src)) elements.
+
+func ToFloat32(dst []float32, src []float64) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = float32(src[i])
+	}
+}
+
+func FromFloat32(dst []float64, src []float32) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = float64(src[i])
+	}
+}
+
+func ToF16(dst []F16, src []float64) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = NewF16(src[i])
+	}
+}
+
+func FromF16(dst []float64, src []F16) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = src[i].Float64()
+	}
+}
+
+func ToBF16(dst []BF16, src []float64) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = NewBF16(src[i])
+	}
+}
+
+func FromBF16(dst []float64, src []BF16) {
+	for i := range min(len(dst), len(src)) {
+		dst[i] = src[i].Float64()
+	}
+}
@@ -0,0 +1,282 @@
+package gemm
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Transpose says whether an operand is used as stored or transposed.
+type Transpose bool
+
+const (
+	NoTrans Transpose = false
+	Trans   Transpose = true
+)
+
+// Block sizes. A kc×nc panel of B (512KB) is meant to sit in L2 while
+// mc×kc of A (128KB) streams through it, and the mr×nr register tile is
+// what the micro-kernel keeps in locals.
+const (
+	mc = 64
+	kc = 256
+	nc = 256
+	mr = 4
+	nr = 4
+)
+
+// parallelWork is the m·n·k below which Gemm stays on the calling
+// goroutine; smaller products finish before goroutines are scheduled.
+const parallelWork = 64 * 64 * 64
+
+var workers atomic.Int32
+
+// SetWorkers caps the goroutines one Gemm call uses. n <= 0 restores the
+// default, GOMAXPROCS.
+func SetWorkers(n int) {
+	workers.Store(int32(max(n, 0)))
+}
+
+func numWorkers() int {
+	if n := int(workers.Load()); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Gemm computes c = op(a)·op(b) + beta·c for row major matrices, where
+// op(a) is m×k, op(b) is k×n and c is m×n. A transposed operand is stored
+// as its transpose: a is k×m when ta is Trans, b is n×k when tb is Trans.
+//
+// c is split into tiles that run on separate goroutines, so a call is
+// deterministic whatever the worker count. c must not overlap a or b.
+func Gemm(ta, tb Transpose, m, n, k int, a, b []float64, beta float64, c []float64) {
+	checkShapes(m, n, k, a, b, c)
+	if m == 0 || n == 0 {
+		return
+	}
+
+	tilesM, tilesN := (m+mc-1)/mc, (n+nc-1)/nc
+	tiles := tilesM * tilesN
+	w := min(numWorkers(), tiles)
+	if m*n*k < parallelWork {
+		w = 1
+	}
+
+	g := gemm{ta: ta, tb: tb, m: m, n: n, k: k, a: a, b: b, beta: beta, c: c}
+	if w == 1 {
+		buf := getBuffers()
+		for t := 0; t < tiles; t++ {
+			g.tile(t/tilesN*mc, t%tilesN*nc, buf)
+		}
+		putBuffers(buf)
+		return
+	}
+
+	var next atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(w)
+	for range w {
+		go func() {
+			defer wg.Done()
+			buf := getBuffers()
+			defer putBuffers(buf)
+			for t := int(next.Add(1)) - 1; t < tiles; t = int(next.Add(1)) - 1 {
+				g.tile(t/tilesN*mc, t%tilesN*nc, buf)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Naive is Gemm as a plain triple loop, the reference the blocked kernel is
+// tested and benchmarked against.
+func Naive(ta, tb Transpose, m, n, k int, a, b []float64, beta float64, c []float64) {
+	checkShapes(m, n, k, a, b, c)
+	for i := 0; i < m; i++ {
+		for j := 0; j < n; j++ {
+			var sum float64
+			for p := 0; p < k; p++ {
+				sum += at(a, ta, i, p, m, k) * at(b, tb, p, j, k, n)
+			}
+			c[i*n+j] = sum + scaled(beta, c[i*n+j])
+		}
+	}
+}
+
+// at returns element (i, j) of op(x), an r×c matrix.
+func at(x []float64, t Transpose, i, j, r, c int) float64 {
+	if t == Trans {
+		return x[j*r+i]
+	}
+	return x[i*c+j]
+}
+
+// scaled is beta·x, except that beta 0 ignores x so an uninitialized c
+// holding NaN does not leak into the result.
+func scaled(beta, x float64) float64 {
+	if beta == 0 {
+		return 0
+	}
+	return beta * x
+}
+
+func checkShapes(m, n, k int, a, b, c []float64) {
+	if m < 0 || n < 0 || k < 0 {
+		panic(fmt.Sprintf("gemm: negative dimension %dx%dx%d", m, n, k))
+	}
+	if len(a) < m*k || len(b) < k*n || len(c) < m*n {
+		panic(fmt.Sprintf("gemm: %dx%dx%d needs len(a) >= %d, len(b) >= %d, len(c) >= %d, got %d, %d, %d",
+			m, n, k, m*k, k*n, m*n, len(a), len(b), len(c)))
+	}
+}
+
+type gemm struct {
+	ta, tb  Transpose
+	m, n, k int
+	a, b    []float64
+	beta    float64
+	c       []float64
+}
+
+// buffers holds one goroutine's packed panels.
+type buffers struct {
+	a []float64 // mc×kc of op(a), in mr row strips
+	b []float64 // kc×nc of op(b), in nr column strips
+}
+
+var bufferPool = sync.Pool{New: func() any {
+	return &buffers{a: make([]float64, mc*kc), b: make([]float64, kc*nc)}
+}}
+
+func getBuffers() *buffers  { return bufferPool.Get().(*buffers) }
+func putBuffers(b *buffers) { bufferPool.Put(b) }
+
+// tile computes the mc×nc tile of c at (i0, j0), running over k in kc
+// slices. The tile is owned by one goroutine, so it needs no locking.
+func (g *gemm) tile(i0, j0 int, buf *buffers) {
+	mb, nb := min(mc, g.m-i0), min(nc, g.n-j0)
+	for i := i0; i < i0+mb; i++ {
+		row := g.c[i*g.n+j0 : i*g.n+j0+nb]
+		for j := range row {
+			row[j] = scaled(g.beta, row[j])
+		}
+	}
+
+	for p0 := 0; p0 < g.k; p0 += kc {
+		kb := min(kc, g.k-p0)
+		g.packA(buf.a, i0, p0, mb, kb)
+		g.packB(buf.b, p0, j0, kb, nb)
+
+		for jr := 0; jr < nb; jr += nr {
+			pb := buf.b[jr*kb : (jr+nr)*kb]
+			for ir := 0; ir < mb; ir += mr {
+				pa := buf.a[ir*kb : (ir+mr)*kb]
+				g.micro(pa, pb, kb, i0+ir, j0+jr, min(mr, mb-ir), min(nr, nb-jr))
+			}
+		}
+	}
+}
+
+// packA copies the mb×kb block of op(a) at (i0, p0) into strips of mr
+// rows, each laid out column by column so the micro-kernel reads it in
+// order. Rows past mb are zero.
+func (g *gemm) packA(dst []float64, i0, p0, mb, kb int) {
+	for ir := 0; ir < mb; ir += mr {
+		strip := dst[ir*kb : (ir+mr)*kb]
+		for r := 0; r < mr; r++ {
+			i := i0 + ir + r
+			if ir+r >= mb {
+				for p := 0; p < kb; p++ {
+					strip[p*mr+r] = 0
+				}
+				continue
+			}
+			if g.ta == Trans {
+				for p := 0; p < kb; p++ {
+					strip[p*mr+r] = g.a[(p0+p)*g.m+i]
+				}
+			} else {
+				row := g.a[i*g.k+p0 : i*g.k+p0+kb]
+				for p, v := range row {
+					strip[p*mr+r] = v
+				}
+			}
+		}
+	}
+}
+
+// packB copies the kb×nb block of op(b) at (p0, j0) into strips of nr
+// columns, each laid out row by row. Columns past nb are zero.
+func (g *gemm) packB(dst []float64, p0, j0, kb, nb int) {
+	for jr := 0; jr < nb; jr += nr {
+		strip := dst[jr*kb : (jr+nr)*kb]
+		for c := 0; c < nr; c++ {
+			j := j0 + jr + c
+			if jr+c >= nb {
+				for p := 0; p < kb; p++ {
+					strip[p*nr+c] = 0
+				}
+				continue
+			}
+			if g.tb == Trans {
+				col := g.b[j*g.k+p0 : j*g.k+p0+kb]
+				for p, v := range col {
+					strip[p*nr+c] = v
+				}
+			} else {
+				for p := 0; p < kb; p++ {
+					strip[p*nr+c] = g.b[(p0+p)*g.n+j]
+				}
+			}
+		}
+	}
+}
+
+// micro adds the product of an mr-row strip of a and an nr-column strip of
+// b into the rows×cols corner of c at (i, j). The 4×4 accumulators live in
+// locals and the loop over k is unrolled across them.
+func (g *gemm) micro(pa, pb []float64, kb, i, j, rows, cols int) {
+	var c00, c01, c02, c03 float64
+	var c10, c11, c12, c13 float64
+	var c20, c21, c22, c23 float64
+	var c30, c31, c32, c33 float64
+
+	pa, pb = pa[:kb*mr], pb[:kb*nr]
+	for p := 0; p < kb; p++ {
+		a := pa[p*mr : p*mr+mr : p*mr+mr]
+		b := pb[p*nr : p*nr+nr : p*nr+nr]
+		a0, a1, a2, a3 := a[0], a[1], a[2], a[3]
+		b0, b1, b2, b3 := b[0], b[1], b[2], b[3]
+		c00 += a0 * b0
+		c01 += a0 * b1
+		c02 += a0 * b2
+		c03 += a0 * b3
+		c10 += a1 * b0
+		c11 += a1 * b1
+		c12 += a1 * b2
+		c13 += a1 * b3
+		c20 += a2 * b0
+		c21 += a2 * b1
+		c22 += a2 * b2
+		c23 += a2 * b3
+		c30 += a3 * b0
+		c31 += a3 * b1
+		c32 += a3 * b2
+		c33 += a3 * b3
+	}
+
+	acc := [mr][nr]float64{
+		{c00, c01, c02, c03},
+		{c10, c11, c12, c13},
+		{c20, c21, c22, c23},
+		{c30, c31, c32, c33},
+	}
+	for r := 0; r < rows; r++ {
+		row := g.c[(i+r)*g.n+j : (i+r)*g.n+j+cols]
+		for c := range row {
+			row[c] += acc[r][c]
+		}
+	}
+}
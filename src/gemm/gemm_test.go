@@ -0,0 +1,148 @@
+package gemm
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func random(rng *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = rng.NormFloat64()
+	}
+	return x
+}
+
+func assertClose(t *testing.T, want, got []float64, msgAndArgs ...any) {
+	t.Helper()
+	require.Len(t, got, len(want))
+	for i := range want {
+		if !assert.InDelta(t, want[i], got[i], 1e-9, msgAndArgs...) {
+			return
+		}
+	}
+}
+
+func TestGemmMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// Shapes straddle the register and cache block sizes so edge tiles and
+	// partial k slices are exercised.
+	shapes := [][3]int{{1, 1, 1}, {3, 5, 7}, {4, 4, 4}, {17, 9, 300}, {65, 257, 33}, {130, 70, 513}}
+	for _, s := range shapes {
+		m, n, k := s[0], s[1], s[2]
+		for _, ta := range []Transpose{NoTrans, Trans} {
+			for _, tb := range []Transpose{NoTrans, Trans} {
+				for _, beta := range []float64{0, 1, 0.5} {
+					name := fmt.Sprintf("%dx%dx%d/ta=%v/tb=%v/beta=%g", m, n, k, ta, tb, beta)
+					a, b, c := random(rng, m*k), random(rng, k*n), random(rng, m*n)
+					want := append([]float64{}, c...)
+					Naive(ta, tb, m, n, k, a, b, beta, want)
+					Gemm(ta, tb, m, n, k, a, b, beta, c)
+					assertClose(t, want, c, name)
+				}
+			}
+		}
+	}
+}
+
+func TestGemmBetaZeroIgnoresC(t *testing.T) {
+	a, b := []float64{1, 2, 3, 4}, []float64{5, 6, 7, 8}
+	c := []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN()}
+	Gemm(NoTrans, NoTrans, 2, 2, 2, a, b, 0, c)
+	assert.Equal(t, []float64{19, 22, 43, 50}, c)
+}
+
+func TestGemmWorkersAgree(t *testing.T) {
+	t.Cleanup(func() { SetWorkers(0) })
+	rng := rand.New(rand.NewSource(2))
+	m, n, k := 200, 600, 300
+	a, b := random(rng, m*k), random(rng, k*n)
+
+	SetWorkers(1)
+	want := make([]float64, m*n)
+	Gemm(NoTrans, NoTrans, m, n, k, a, b, 0, want)
+
+	// Each tile is summed in the same order whatever goroutine runs it
+	for _, w := range []int{2, 3, 8} {
+		SetWorkers(w)
+		got := make([]float64, m*n)
+		Gemm(NoTrans, NoTrans, m, n, k, a, b, 0, got)
+		assert.Equal(t, want, got, "workers=%d", w)
+	}
+}
+
+func TestGemmBackwardProducts(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	m, k, n := 6, 5, 7
+	a, b, dc := random(rng, m*k), random(rng, k*n), random(rng, m*n)
+
+	// d/da sum(dc ⊙ a·b) is dc·bᵀ, d/db is aᵀ·dc, checked element by element
+	wantA := make([]float64, m*k)
+	for i := 0; i < m; i++ {
+		for p := 0; p < k; p++ {
+			for j := 0; j < n; j++ {
+				wantA[i*k+p] += dc[i*n+j] * b[p*n+j]
+			}
+		}
+	}
+	wantB := make([]float64, k*n)
+	for p := 0; p < k; p++ {
+		for j := 0; j < n; j++ {
+			for i := 0; i < m; i++ {
+				wantB[p*n+j] += a[i*k+p] * dc[i*n+j]
+			}
+		}
+	}
+
+	// With beta 1 the gradients accumulate into what is already there
+	da, db := make([]float64, m*k), make([]float64, k*n)
+	da[0], db[0] = 1, 1
+	wantA[0]++
+	wantB[0]++
+	Gemm(NoTrans, Trans, m, k, n, dc, b, 1, da)
+	Gemm(Trans, NoTrans, k, n, m, a, dc, 1, db)
+	assertClose(t, wantA, da)
+	assertClose(t, wantB, db)
+}
+
+func TestGemmShapeErrors(t *testing.T) {
+	assert.PanicsWithValue(t,
+		"gemm: 2x2x2 needs len(a) >= 4, len(b) >= 4, len(c) >= 4, got 3, 4, 4",
+		func() { Gemm(NoTrans, NoTrans, 2, 2, 2, make([]float64, 3), make([]float64, 4), 0, make([]float64, 4)) })
+	assert.Panics(t, func() { Gemm(NoTrans, NoTrans, -1, 0, 0, nil, nil, 0, nil) })
+}
+
+// LLM-relevant shapes: an MLP up projection (tokens × d_model · d_model ×
+// 4·d_model) and its backward products at GPT-2 small width.
+var benchShapes = []struct {
+	name    string
+	ta, tb  Transpose
+	m, n, k int
+}{
+	{"768x3072x768", NoTrans, NoTrans, 768, 3072, 768},
+	{"128x3072x768", NoTrans, NoTrans, 128, 3072, 768},
+	{"128x768x3072/TransB", NoTrans, Trans, 128, 768, 3072},
+	{"768x3072x128/TransA", Trans, NoTrans, 768, 3072, 128},
+}
+
+func benchmark(b *testing.B, f func(ta, tb Transpose, m, n, k int, a, bm []float64, beta float64, c []float64)) {
+	for _, s := range benchShapes {
+		b.Run(s.name, func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			a, bm, c := random(rng, s.m*s.k), random(rng, s.k*s.n), make([]float64, s.m*s.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f(s.ta, s.tb, s.m, s.n, s.k, a, bm, 0, c)
+			}
+			b.ReportMetric(2*float64(s.m*s.n*s.k)*float64(b.N)/b.Elapsed().Seconds()/1e9, "GFLOPS")
+		})
+	}
+}
+
+func BenchmarkGemm(b *testing.B)  { benchmark(b, Gemm) }
+func BenchmarkNaive(b *testing.B) { benchmark(b, Naive) }
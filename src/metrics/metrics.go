@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Standard metric names. Sinks accept any name, these are the ones the
+// trainer emits and nanollm plot charts.
+const (
+	Loss         = "loss"
+	ValLoss      = "val_loss"
+	LR           = "lr"
+	GradNorm     = "grad_norm"
+	TokensPerSec = "tokens_per_sec"
+	StepTimeMS   = "step_time_ms"
+	HeapBytes    = "heap_bytes"
+	LossScale    = "loss_scale"
+)
+
+// Columns is the default column order for tabular sinks.
+var Columns = []string{Loss, ValLoss, LR, GradNorm, TokensPerSec, StepTimeMS, HeapBytes, LossScale}
+
+// Record holds the metrics measured at one step. A record only carries the
+// metrics that were measured; evaluation records, for example, hold just
+// the validation loss.
+type Record struct {
+	Step   int
+	Values map[string]float64
+}
+
+// Run describes a training run so logged metrics can be traced back to the
+// code and settings that produced them.
+type Run struct {
+	StartTime   time.Time `json:"start_time"`
+	ConfigHash  string    `json:"config_hash"`
+	GitRevision string    `json:"git_revision"`
+	Seed        int64     `json:"seed"`
+}
+
+// Logger receives run metadata once, then metric records in step order.
+type Logger interface {
+	Start(run Run) error
+	Log(rec Record) error
+	Close() error
+}
+
+// NewRun fills in the metadata for a run of config with the given seed.
+func NewRun(config any, seed int64) Run {
+	return Run{
+		StartTime:   time.Now().UTC(),
+		ConfigHash:  ConfigHash(config),
+		GitRevision: GitRevision(),
+		Seed:        seed,
+	}
+}
+
+// ConfigHash returns a short, stable fingerprint of a JSON encodable
+// config. Two runs with the same hash used the same settings.
+func ConfigHash(config any) string {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "unknown"
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// GitRevision reports the commit the binary was built from. Binaries made
+// with go run carry no VCS stamp, so it falls back to asking git and returns
+// "unknown" when that fails too.
+func GitRevision() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var rev, modified string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				rev = s.Value
+			case "vcs.modified":
+				modified = s.Value
+			}
+		}
+		if rev != "" {
+			if modified == "true" {
+				rev += "-dirty"
+			}
+			return rev
+		}
+	}
+
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "unknown"
+	}
+	rev := strings.TrimSpace(string(out))
+	if status, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(status) > 0 {
+		rev += "-dirty"
+	}
+
+	return rev
+}
+
+type multi []Logger
+
+// Multi fans every call out to all loggers and joins their errors.
+func Multi(loggers ...Logger) Logger {
+	return multi(loggers)
+}
+
+func (m multi) Start(run Run) error {
+	var errs []error
+	for _, l := range m {
+		errs = append(errs, l.Start(run))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multi) Log(rec Record) error {
+	var errs []error
+	for _, l := range m {
+		errs = append(errs, l.Log(rec))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multi) Close() error {
+	var errs []error
+	for _, l := range m {
+		errs = append(errs, l.Close())
+	}
+	return errors.Join(errs...)
+}
+
+type discard struct{}
+
+// Discard drops everything it is given.
+var Discard Logger = discard{}
+
+func (discard) Start(Run) error  { return nil }
+func (discard) Log(Record) error { return nil }
+func (discard) Close() error     { return nil }
+
+// OpenFile creates a file sink, picking JSONL or CSV from the extension.
+// Closing the logger closes the file.
+func OpenFile(path string) (Logger, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".jsonl" && ext != ".csv" {
+		return nil, fmt.Errorf("metrics file %s: extension must be .jsonl or .csv", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext == ".csv" {
+		return &closer{Logger: NewCSV(f, Columns), f: f}, nil
+	}
+	return &closer{Logger: NewJSONL(f), f: f}, nil
+}
+
+type closer struct {
+	Logger
+	f *os.File
+}
+
+func (c *closer) Close() error {
+	return errors.Join(c.Logger.Close(), c.f.Close())
+}
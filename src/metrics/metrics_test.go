@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRun = Run{
+	StartTime:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	ConfigHash:  "abc123",
+	GitRevision: "deadbeef",
+	Seed:        42,
+}
+
+func TestJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	j := NewJSONL(&buf)
+	require.NoError(t, j.Start(testRun))
+	require.NoError(t, j.Log(Record{Step: 3, Values: map[string]float64{Loss: 1.5, LR: 0.01, GradNorm: math.NaN()}}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var header struct{ Run Run }
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, testRun, header.Run)
+
+	var rec map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &rec))
+	assert.Equal(t, map[string]float64{"step": 3, Loss: 1.5, LR: 0.01}, rec, "NaN values should be dropped")
+}
+
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCSV(&buf, []string{Loss, ValLoss})
+	require.NoError(t, c.Start(testRun))
+	require.NoError(t, c.Log(Record{Step: 0, Values: map[string]float64{Loss: 2.25, LR: 0.1}}))
+	require.NoError(t, c.Log(Record{Step: 1, Values: map[string]float64{ValLoss: 2}}))
+	require.NoError(t, c.Close())
+
+	assert.Equal(t, `# start_time: 2024-05-01T12:00:00Z
+# config_hash: abc123
+# git_revision: deadbeef
+# seed: 42
+step,loss,val_loss
+0,2.25,
+1,,2
+`, buf.String())
+}
+
+func TestTable(t *testing.T) {
+	var buf bytes.Buffer
+	tbl := NewTable(&buf, []string{Loss, LR})
+	require.NoError(t, tbl.Start(testRun))
+	require.NoError(t, tbl.Log(Record{Step: 7, Values: map[string]float64{Loss: 1.23456, LR: 0.003}}))
+	require.NoError(t, tbl.Log(Record{Step: 8, Values: map[string]float64{Loss: 1}}))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[0], "config abc123")
+	assert.Contains(t, lines[0], "seed 42")
+	assert.Equal(t, strings.Fields(lines[1]), []string{"step", "loss", "lr"})
+	assert.Equal(t, strings.Fields(lines[2]), []string{"7", "1.2346", "3.00e-03"})
+	assert.Equal(t, strings.Fields(lines[3]), []string{"8", "1.0000", "-"})
+	assert.Equal(t, len(lines[1]), len(lines[2]), "Columns should line up")
+}
+
+func TestMemory(t *testing.T) {
+	m := &Memory{}
+	require.NoError(t, m.Start(testRun))
+
+	values := map[string]float64{Loss: 3}
+	require.NoError(t, m.Log(Record{Step: 0, Values: values}))
+	values[Loss] = 100 // the logger must keep its own copy
+	require.NoError(t, m.Log(Record{Step: 1, Values: map[string]float64{ValLoss: 2}}))
+	require.NoError(t, m.Log(Record{Step: 2, Values: map[string]float64{Loss: 1}}))
+	require.NoError(t, m.Close())
+
+	steps, losses := m.Series(Loss)
+	assert.Equal(t, []int{0, 2}, steps)
+	assert.Equal(t, []float64{3, 1}, losses)
+	assert.Equal(t, testRun, m.Run)
+	assert.True(t, m.Closed)
+}
+
+type failing struct{ Memory }
+
+func (f *failing) Log(Record) error { return errors.New("disk full") }
+
+func TestMultiFansOut(t *testing.T) {
+	a, b := &Memory{}, &failing{}
+	l := Multi(a, b)
+	require.NoError(t, l.Start(testRun))
+
+	err := l.Log(Record{Step: 0, Values: map[string]float64{Loss: 1}})
+	assert.ErrorContains(t, err, "disk full")
+	assert.Len(t, a.Records, 1, "A failing sink should not starve the others")
+
+	require.NoError(t, l.Close())
+	assert.True(t, a.Closed)
+	assert.True(t, b.Closed)
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"run/m.jsonl", "run/m.csv"} {
+		path := filepath.Join(dir, name)
+		l, err := OpenFile(path)
+		require.NoError(t, err)
+		require.NoError(t, l.Start(testRun))
+		require.NoError(t, l.Log(Record{Step: 0, Values: map[string]float64{Loss: 1}}))
+		require.NoError(t, l.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "deadbeef")
+	}
+
+	_, err := OpenFile(filepath.Join(dir, "m.txt"))
+	assert.Error(t, err)
+}
+
+func TestConfigHash(t *testing.T) {
+	type cfg struct {
+		LR    float64
+		Steps int
+	}
+
+	assert.Equal(t, ConfigHash(cfg{0.1, 10}), ConfigHash(cfg{0.1, 10}))
+	assert.NotEqual(t, ConfigHash(cfg{0.1, 10}), ConfigHash(cfg{0.1, 11}))
+	assert.Len(t, ConfigHash(cfg{}), 12)
+}
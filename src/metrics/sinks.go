@@ -0,0 +1,240 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONL writes one JSON object per line: the run metadata under a "run" key
+// first, then {"step": n, "<metric>": value, ...} for every record. This is
+// the format nanollm plot reads.
+type JSONL struct {
+	w io.Writer
+}
+
+func NewJSONL(w io.Writer) *JSONL {
+	return &JSONL{w: w}
+}
+
+func (j *JSONL) Start(run Run) error {
+	return j.write(map[string]any{"run": run})
+}
+
+func (j *JSONL) Log(rec Record) error {
+	line := map[string]any{"step": rec.Step}
+	for name, v := range rec.Values {
+		// JSON has no NaN or Inf, so a diverged value is left out of its record
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			line[name] = v
+		}
+	}
+
+	return j.write(line)
+}
+
+func (j *JSONL) Close() error {
+	return nil
+}
+
+func (j *JSONL) write(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(append(line, '\n'))
+	return err
+}
+
+// CSV writes the run metadata as "# key: value" comment lines, then a header
+// of step plus the configured columns, then one row per record. Metrics a
+// record does not carry are left empty and metrics outside the columns are
+// dropped.
+type CSV struct {
+	out     io.Writer
+	w       *csv.Writer
+	columns []string
+}
+
+func NewCSV(w io.Writer, columns []string) *CSV {
+	return &CSV{out: w, w: csv.NewWriter(w), columns: columns}
+}
+
+func (c *CSV) Start(run Run) error {
+	// Comments go straight to the writer so the csv.Writer cannot quote them
+	for _, kv := range runFields(run) {
+		if _, err := fmt.Fprintf(c.out, "# %s\n", kv); err != nil {
+			return err
+		}
+	}
+
+	return c.header()
+}
+
+func (c *CSV) header() error {
+	if err := c.w.Write(append([]string{"step"}, c.columns...)); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSV) Log(rec Record) error {
+	row := make([]string, 0, len(c.columns)+1)
+	row = append(row, strconv.Itoa(rec.Step))
+	for _, name := range c.columns {
+		v, ok := rec.Values[name]
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			row = append(row, "")
+			continue
+		}
+		row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+	}
+
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *CSV) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Table prints records as aligned columns for humans watching a run.
+type Table struct {
+	w       io.Writer
+	columns []string
+}
+
+func NewTable(w io.Writer, columns []string) *Table {
+	return &Table{w: w, columns: columns}
+}
+
+// tableWidth is the minimum column width, wider names widen their column.
+const tableWidth = 12
+
+func columnWidth(name string) int {
+	return max(tableWidth, len(name))
+}
+
+func (t *Table) Start(run Run) error {
+	if _, err := fmt.Fprintf(t.w, "run %s | config %s | git %s | seed %d\n",
+		run.StartTime.Format("2006-01-02 15:04:05"), run.ConfigHash, run.GitRevision, run.Seed); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%6s", "step")
+	for _, name := range t.columns {
+		fmt.Fprintf(&b, " %*s", columnWidth(name), name)
+	}
+	_, err := fmt.Fprintln(t.w, b.String())
+	return err
+}
+
+func (t *Table) Log(rec Record) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%6d", rec.Step)
+	for _, name := range t.columns {
+		v, ok := rec.Values[name]
+		if !ok {
+			fmt.Fprintf(&b, " %*s", columnWidth(name), "-")
+			continue
+		}
+		fmt.Fprintf(&b, " %*s", columnWidth(name), formatValue(name, v))
+	}
+
+	_, err := fmt.Fprintln(t.w, b.String())
+	return err
+}
+
+func (t *Table) Close() error {
+	return nil
+}
+
+func formatValue(name string, v float64) string {
+	switch name {
+	case LR:
+		return strconv.FormatFloat(v, 'e', 2, 64)
+	case HeapBytes:
+		return fmt.Sprintf("%.1fMiB", v/(1<<20))
+	case TokensPerSec:
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case StepTimeMS:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case LossScale:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// Memory keeps everything in memory, for tests and for callers that want
+// to inspect a run after it finished.
+type Memory struct {
+	mu      sync.Mutex
+	Run     Run
+	Records []Record
+	Closed  bool
+}
+
+func (m *Memory) Start(run Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Run = run
+	return nil
+}
+
+func (m *Memory) Log(rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make(map[string]float64, len(rec.Values))
+	for k, v := range rec.Values {
+		values[k] = v
+	}
+	m.Records = append(m.Records, Record{Step: rec.Step, Values: values})
+	return nil
+}
+
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Closed = true
+	return nil
+}
+
+// Series returns the steps and values of every record carrying name.
+func (m *Memory) Series(name string) (steps []int, values []float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rec := range m.Records {
+		if v, ok := rec.Values[name]; ok {
+			steps = append(steps, rec.Step)
+			values = append(values, v)
+		}
+	}
+
+	return steps, values
+}
+
+func runFields(run Run) []string {
+	return []string{
+		"start_time: " + run.StartTime.Format(time.RFC3339),
+		"config_hash: " + run.ConfigHash,
+		"git_revision: " + run.GitRevision,
+		"seed: " + strconv.FormatInt(run.Seed, 10),
+	}
+}
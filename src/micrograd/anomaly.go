@@ -0,0 +1,122 @@
+package micrograd
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+var anomalyDepth atomic.Int32
+
+// AnomalyError describes the first NaN or Inf found in anomaly mode.
+type AnomalyError struct {
+	// Phase is "forward" when the op produced the bad value and "backward"
+	// when its backward pass did.
+	Phase  string
+	Op     string
+	Inputs []float64
+	// GradOut is the gradient flowing into the op, for backward anomalies.
+	GradOut float64
+	Reason  string
+	// Stack is where the offending node was created.
+	Stack string
+}
+
+func (e *AnomalyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "micrograd: anomaly in %s of op %q with inputs %v", e.Phase, e.Op, e.Inputs)
+	if e.Phase == "backward" {
+		fmt.Fprintf(&b, " and output gradient %g", e.GradOut)
+	}
+	fmt.Fprintf(&b, ": %s", e.Reason)
+	if e.Stack != "" {
+		fmt.Fprintf(&b, "\nnode created at:\n%s", e.Stack)
+	}
+
+	return b.String()
+}
+
+// DetectAnomaly runs fn in anomaly mode and returns the first anomaly as an
+// *AnomalyError. In anomaly mode every recorded op checks its result, and
+// Backward checks the gradients each op hands its inputs, for NaN and Inf.
+// Nodes also remember where they were created, which slows graph building
+// down considerably, so use it to debug rather than to train.
+//
+// Like NoGrad the mode is process wide. An anomaly in another goroutine
+// while fn runs panics there, since it has no DetectAnomaly to return to.
+func DetectAnomaly(fn func()) (err error) {
+	anomalyDepth.Add(1)
+	defer func() {
+		anomalyDepth.Add(-1)
+		if r := recover(); r != nil {
+			anomaly, ok := r.(*AnomalyError)
+			if !ok {
+				panic(r)
+			}
+			err = anomaly
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+func anomalyEnabled() bool {
+	return anomalyDepth.Load() > 0
+}
+
+func finite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
+
+func (v *Value) recordStack() {
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, recordStack and convertToValue
+	v.stack = pcs[:runtime.Callers(3, pcs)]
+}
+
+func (v *Value) anomaly(phase, reason string, args ...any) *AnomalyError {
+	inputs := make([]float64, len(v.children))
+	for i, c := range v.children {
+		inputs[i] = c.data
+	}
+
+	return &AnomalyError{
+		Phase:   phase,
+		Op:      v.op,
+		Inputs:  inputs,
+		GradOut: v.grad,
+		Reason:  fmt.Sprintf(reason, args...),
+		Stack:   formatStack(v.stack),
+	}
+}
+
+// checkGrads runs after v's backward and blames v for any input gradient
+// that stopped being finite.
+func (v *Value) checkGrads() {
+	for i, c := range v.children {
+		if !finite(c.grad) {
+			panic(v.anomaly("backward", "gradient for input %d is %g", i, c.grad))
+		}
+	}
+}
+
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
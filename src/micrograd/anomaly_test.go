@@ -0,0 +1,94 @@
+package micrograd
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomalyDivideByZero(t *testing.T) {
+	err := DetectAnomaly(func() {
+		NewValue(1).Div(NewValue(0))
+	})
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "forward", anomaly.Phase)
+	assert.Equal(t, "**-1.000000", anomaly.Op)
+	assert.Equal(t, []float64{0, -1}, anomaly.Inputs)
+	assert.Contains(t, anomaly.Stack, "TestAnomalyDivideByZero", "The stack should point at the code that built the node")
+}
+
+func TestAnomalyLogOfNegative(t *testing.T) {
+	err := DetectAnomaly(func() {
+		NewValue(3).Sub(NewValue(5)).Log()
+	})
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "log", anomaly.Op)
+	assert.Equal(t, []float64{-2}, anomaly.Inputs)
+	assert.Contains(t, err.Error(), "NaN")
+}
+
+func TestAnomalyInBackward(t *testing.T) {
+	// Both forward values are finite, but d/dx = 1e200 / 1e-200 overflows
+	x := NewValue(1e-200)
+	y := x.Log().MulScalar(1e200)
+
+	err := DetectAnomaly(y.Backward)
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "backward", anomaly.Phase)
+	assert.Equal(t, "log", anomaly.Op)
+	assert.Equal(t, 1e200, anomaly.GradOut)
+	assert.Empty(t, anomaly.Stack, "Nodes built outside anomaly mode have no stack")
+}
+
+func TestAnomalyPowNegativeBaseWithVariableExponent(t *testing.T) {
+	err := DetectAnomaly(func() {
+		base := NewValue(-2)
+		exp := NewValue(2)
+		base.Pow(exp).Backward()
+	})
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "backward", anomaly.Phase)
+	assert.Contains(t, anomaly.Reason, "ln(-2)")
+}
+
+func TestAnomalyPowNegativeBaseWithConstantExponent(t *testing.T) {
+	var a *Value
+	err := DetectAnomaly(func() {
+		a = NewValue(-4)
+		a.PowScalar(2).Backward()
+	})
+
+	require.NoError(t, err, "A constant exponent needs no gradient, so skipping ln is fine")
+	assert.InDelta(t, -8.0, a.grad, 1e-9)
+}
+
+func TestAnomalyModeScope(t *testing.T) {
+	require.NoError(t, DetectAnomaly(func() {
+		x := NewValue(2)
+		x.Multiply(x).Backward()
+	}))
+	assert.False(t, anomalyEnabled())
+
+	// Outside anomaly mode NaN flows through unchecked and no stacks are kept
+	nan := NewValue(-1).Log()
+	assert.True(t, math.IsNaN(nan.data))
+	assert.Nil(t, nan.stack)
+
+	// Other panics are not swallowed
+	boom := errors.New("boom")
+	assert.PanicsWithValue(t, boom, func() {
+		_ = DetectAnomaly(func() { panic(boom) })
+	})
+	assert.False(t, anomalyEnabled())
+}
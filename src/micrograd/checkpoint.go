@@ -0,0 +1,66 @@
+package micrograd
+
+// Checkpoint runs fn on inputs without keeping the nodes it creates, and
+// returns outputs that recompute them during Backward. Only the inputs and
+// outputs stay alive between forward and backward, so checkpointing every
+// layer of a deep network keeps a layer's worth of graph in memory instead
+// of the whole network's. Each segment runs fn twice.
+//
+// fn must compute the same thing both times. Values it uses other than
+// inputs, such as the weights it closes over, must be leaves; they get
+// their gradients when the segment is recomputed, outside the locking
+// BackwardParallel does, so segments sharing weights must not be
+// differentiated in parallel. Grad, Compile and WriteGraph do not support
+// checkpointed graphs.
+func Checkpoint(fn func([]*Value) []*Value, inputs []*Value) []*Value {
+	if !GradEnabled() {
+		return fn(inputs)
+	}
+
+	// The forward graph is dropped as soon as the output data is read. It is
+	// recorded rather than built under NoGrad, which would switch recording
+	// off on every goroutine.
+	outs := fn(detach(inputs))
+
+	// The outputs all depend on one segment node, so Backward reaches it
+	// once, after every output has its gradient.
+	segment := convertToValue(0, append([]*Value(nil), inputs...), "checkpoint")
+	results := make([]*Value, len(outs))
+	gradOuts := make([]float64, len(outs))
+	for i, o := range outs {
+		out := convertToValue(o.data, []*Value{segment}, "checkpoint output")
+		out.backward = func() { gradOuts[i] += out.grad }
+		results[i] = out
+	}
+
+	segment.backward = func() {
+		leaves := detach(inputs)
+		var terms []*Value
+		for i, o := range fn(leaves) {
+			if gradOuts[i] != 0 {
+				terms = append(terms, o.MulScalar(gradOuts[i]))
+			}
+		}
+		if len(terms) == 0 {
+			return
+		}
+
+		Sum(terms).Backward()
+		for i, in := range inputs {
+			in.grad += leaves[i].grad
+		}
+		clear(gradOuts)
+	}
+
+	return results
+}
+
+// detach returns leaves holding the data of values.
+func detach(values []*Value) []*Value {
+	leaves := make([]*Value, len(values))
+	for i, v := range values {
+		leaves[i] = NewValue(v.data)
+	}
+
+	return leaves
+}
@@ -0,0 +1,128 @@
+package micrograd
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deepLoss runs x through every layer of mlp, checkpointing each layer when
+// asked, and returns a squared loss.
+func deepLoss(mlp *MLP, x []*Value, checkpoint bool) *Value {
+	for _, layer := range mlp.Layers {
+		if checkpoint {
+			x = Checkpoint(layer.Call, x)
+		} else {
+			x = layer.Call(x)
+		}
+	}
+
+	var squares []*Value
+	for _, v := range x {
+		squares = append(squares, v.Multiply(v))
+	}
+	return Mean(squares)
+}
+
+func newDeepMLP(depth, width int) *MLP {
+	sizes := make([]int, depth)
+	for i := range sizes {
+		sizes[i] = width
+	}
+	return NewMLP(width, sizes, rand.New(rand.NewSource(3)))
+}
+
+func deepInput(width int) []*Value {
+	x := make([]*Value, width)
+	for i := range x {
+		x[i] = NewValue(float64(i%5) - 2)
+	}
+	return x
+}
+
+func TestCheckpointGradients(t *testing.T) {
+	mlp := newDeepMLP(6, 8)
+	x := deepInput(8)
+
+	plain := deepLoss(mlp, x, false)
+	plain.Backward()
+	params := mlp.Parameters()
+	want := leafGrads(append(params, x...))
+
+	ZeroGrad(mlp)
+	for _, v := range x {
+		v.grad = 0
+	}
+	checkpointed := deepLoss(mlp, x, true)
+	assert.Equal(t, plain.data, checkpointed.data)
+	checkpointed.Backward()
+	assert.InDeltaSlice(t, want, leafGrads(append(params, x...)), 1e-12)
+}
+
+func TestCheckpointMultipleUses(t *testing.T) {
+	a, b := NewValue(1.5), NewValue(-0.5)
+	w := NewValue(2)
+	segment := func(in []*Value) []*Value {
+		s := in[0].Multiply(w).Add(in[1])
+		return []*Value{s.Exp(), s.Multiply(in[0]), in[1]}
+	}
+
+	build := func(checkpoint bool) *Value {
+		in := []*Value{a.MulScalar(3), b}
+		var out []*Value
+		if checkpoint {
+			out = Checkpoint(segment, in)
+		} else {
+			out = segment(in)
+		}
+		// Outputs feed each other and one is unused by the loss
+		return out[0].Add(out[1].Multiply(out[0])).Add(out[2].PowScalar(2))
+	}
+
+	leaves := []*Value{a, b, w}
+	build(false).Backward()
+	want := leafGrads(leaves)
+	for _, v := range leaves {
+		v.grad = 0
+	}
+	build(true).Backward()
+	assert.InDeltaSlice(t, want, leafGrads(leaves), 1e-12)
+
+}
+
+func TestCheckpointUnderNoGrad(t *testing.T) {
+	x := NewValue(2)
+	NoGrad(func() {
+		out := Checkpoint(func(in []*Value) []*Value { return []*Value{in[0].Exp()} }, []*Value{x})
+		assert.Empty(t, out[0].children)
+	})
+}
+
+// liveHeap returns the heap in use after a collection.
+func liveHeap() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestCheckpointSavesMemory(t *testing.T) {
+	mlp := newDeepMLP(16, 24)
+	x := deepInput(24)
+
+	measure := func(checkpoint bool) uint64 {
+		before := liveHeap()
+		loss := deepLoss(mlp, x, checkpoint)
+		after := liveHeap()
+		runtime.KeepAlive(loss)
+		require.Greater(t, after, before)
+		return after - before
+	}
+
+	plain, checkpointed := measure(false), measure(true)
+	t.Logf("graph kept for backward: %d bytes plain, %d checkpointed", plain, checkpointed)
+	assert.Less(t, checkpointed*5, plain, "Checkpointing should keep a fraction of the graph alive")
+}
@@ -0,0 +1,183 @@
+package micrograd
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"strconv"
+)
+
+// WriteGo writes a Go source file for package pkg with a function name that
+// computes the tape's output and the gradient with respect to each input in
+// straight-line arithmetic:
+//
+//	func name(x [N]float64) (out float64, grad [N]float64)
+//
+// The file only imports math, so it can be embedded without this package.
+// The tape is optimized first. Tapes containing Functions cannot be
+// generated.
+func (t *Tape) WriteGo(w io.Writer, pkg, name string) error {
+	t = t.Optimize()
+	g := &goGen{t: t, inputs: make(map[int32]int)}
+	for i, s := range t.inputs {
+		g.inputs[s] = i
+	}
+	g.outputs = make(map[int32]bool, len(t.code))
+	for _, ins := range t.code {
+		if ins.op == opFunc {
+			return fmt.Errorf("generate %s: Function %s has no Go translation", name, FunctionName(t.fns[ins.fn]))
+		}
+		g.outputs[ins.out] = true
+	}
+
+	var body bytes.Buffer
+	g.body(&body)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by micrograd. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if g.usesMath {
+		fmt.Fprintf(&src, "import \"math\"\n\n")
+	}
+	n := len(t.inputs)
+	fmt.Fprintf(&src, "// %s returns the output of the graph at x and its gradient with respect to\n// each element of x.\n", name)
+	fmt.Fprintf(&src, "func %s(x [%d]float64) (out float64, grad [%d]float64) {\n", name, n, n)
+	src.Write(body.Bytes())
+	fmt.Fprintf(&src, "}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("generate %s: %w", name, err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+type goGen struct {
+	t        *Tape
+	inputs   map[int32]int
+	outputs  map[int32]bool // slots computed by an instruction
+	usesMath bool
+}
+
+// value is the expression holding slot s's data.
+func (g *goGen) value(s int32) string {
+	if i, ok := g.inputs[s]; ok {
+		return fmt.Sprintf("x[%d]", i)
+	}
+	if g.outputs[s] {
+		return fmt.Sprintf("v%d", s)
+	}
+
+	x := g.t.data[s]
+	switch {
+	case math.IsNaN(x):
+		g.usesMath = true
+		return "math.NaN()"
+	case math.IsInf(x, 0):
+		g.usesMath = true
+		return fmt.Sprintf("math.Inf(%d)", int(math.Copysign(1, x)))
+	case x < 0:
+		return "(" + strconv.FormatFloat(x, 'g', -1, 64) + ")"
+	default:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+}
+
+// grad is the variable accumulating slot s's gradient, or "" for constants,
+// whose gradients are not needed.
+func (g *goGen) grad(s int32) string {
+	if i, ok := g.inputs[s]; ok {
+		return fmt.Sprintf("grad[%d]", i)
+	}
+	if g.outputs[s] {
+		return fmt.Sprintf("g%d", s)
+	}
+
+	return ""
+}
+
+func (g *goGen) body(w *bytes.Buffer) {
+	t := g.t
+	for _, ins := range t.code {
+		a, b := g.value(ins.a), ""
+		if ins.op.binary() {
+			b = g.value(ins.b)
+		}
+		var expr string
+		switch ins.op {
+		case opAdd, opAddConst:
+			expr = a + " + " + b
+		case opMul:
+			expr = a + " * " + b
+		case opPow:
+			expr = fmt.Sprintf("math.Pow(%s, %s)", a, b)
+		case opReLU:
+			expr = fmt.Sprintf("math.Max(0, %s)", a)
+		case opExp:
+			expr = fmt.Sprintf("math.Exp(%s)", a)
+		case opLog:
+			expr = fmt.Sprintf("math.Log(%s)", a)
+		}
+		if ins.op >= opPow {
+			g.usesMath = true
+		}
+		fmt.Fprintf(w, "v%d := %s\n", ins.out, expr)
+	}
+	fmt.Fprintf(w, "out = %s\n", g.value(t.output))
+	if len(t.code) == 0 {
+		if gout := g.grad(t.output); gout != "" {
+			fmt.Fprintf(w, "%s = 1\n", gout)
+		}
+		fmt.Fprintf(w, "return out, grad\n")
+		return
+	}
+
+	fmt.Fprintf(w, "\n")
+	for _, ins := range t.code {
+		fmt.Fprintf(w, "var g%d float64\n", ins.out)
+	}
+	fmt.Fprintf(w, "g%d = 1\n", t.output)
+
+	acc := func(s int32, format string, args ...any) {
+		if v := g.grad(s); v != "" {
+			fmt.Fprintf(w, "%s += "+format+"\n", append([]any{v}, args...)...)
+		}
+	}
+	for i := len(t.code) - 1; i >= 0; i-- {
+		ins := t.code[i]
+		gout := fmt.Sprintf("g%d", ins.out)
+		a, b, out := g.value(ins.a), "", g.value(ins.out)
+		if ins.op.binary() {
+			b = g.value(ins.b)
+		}
+		switch ins.op {
+		case opAdd:
+			acc(ins.a, "%s", gout)
+			acc(ins.b, "%s", gout)
+		case opAddConst:
+			acc(ins.a, "%s", gout)
+		case opMul:
+			acc(ins.a, "%s * %s", b, gout)
+			acc(ins.b, "%s * %s", a, gout)
+		case opPow:
+			// Same guards as Value.Pow
+			if v := g.grad(ins.a); v != "" {
+				fmt.Fprintf(w, "if %s != 0 {\n%s += %s * math.Pow(%s, %s-1) * %s\n}\n", a, v, b, a, b, gout)
+			}
+			if v := g.grad(ins.b); v != "" {
+				fmt.Fprintf(w, "if %s > 0 {\n%s += math.Log(%s) * %s * %s\n}\n", a, v, a, out, gout)
+			}
+		case opReLU:
+			if v := g.grad(ins.a); v != "" {
+				fmt.Fprintf(w, "if %s > 0 {\n%s += %s\n}\n", a, v, gout)
+			}
+		case opExp:
+			acc(ins.a, "%s * %s", out, gout)
+		case opLog:
+			acc(ins.a, "%s / %s", gout, a)
+		}
+	}
+	fmt.Fprintf(w, "return out, grad\n")
+}
@@ -0,0 +1,125 @@
+package micrograd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generated is one function to generate and the points to run it at.
+type generated struct {
+	name   string
+	tape   *Tape
+	inputs [][]float64
+}
+
+// runGenerated writes each function into a throwaway module with a main
+// that prints its results at every point, builds it with the go tool and
+// returns the outputs and gradients, indexed by function then point.
+func runGenerated(t *testing.T, funcs []generated) (outs [][]float64, grads [][][]float64) {
+	t.Helper()
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module generated\n\ngo 1.21\n"), 0o644))
+
+	var main bytes.Buffer
+	fmt.Fprintf(&main, "package main\n\nimport (\n\t\"encoding/json\"\n\t\"os\"\n)\n\nfunc main() {\n")
+	fmt.Fprintf(&main, "\tvar outs []float64\n\tvar grads [][]float64\n")
+	for i, f := range funcs {
+		var src bytes.Buffer
+		require.NoError(t, f.tape.WriteGo(&src, "main", f.name))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), src.Bytes(), 0o644))
+
+		for _, x := range f.inputs {
+			fmt.Fprintf(&main, "\t{\n\t\tout, grad := %s(%#v)\n", f.name, x)
+			fmt.Fprintf(&main, "\t\touts = append(outs, out)\n\t\tgrads = append(grads, grad[:])\n\t}\n")
+		}
+	}
+	fmt.Fprintf(&main, "\tjson.NewEncoder(os.Stdout).Encode([]any{outs, grads})\n}\n")
+	// The points are printed as []float64 literals; make them arrays.
+	src := bytes.ReplaceAll(main.Bytes(), []byte("[]float64{"), []byte("[...]float64{"))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), src, 0o644))
+
+	cmd := exec.Command(gobin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+	stdout, err := cmd.Output()
+	if exit, ok := err.(*exec.ExitError); ok {
+		t.Fatalf("generated code failed: %v\n%s", err, exit.Stderr)
+	}
+	require.NoError(t, err)
+
+	var result struct {
+		outs  []float64
+		grads [][]float64
+	}
+	raw := []any{&result.outs, &result.grads}
+	require.NoError(t, json.Unmarshal(stdout, &raw))
+
+	// Split the flat results back up by function.
+	for _, f := range funcs {
+		outs = append(outs, result.outs[:len(f.inputs)])
+		grads = append(grads, result.grads[:len(f.inputs)])
+		result.outs, result.grads = result.outs[len(f.inputs):], result.grads[len(f.inputs):]
+	}
+	return outs, grads
+}
+
+func TestWriteGo(t *testing.T) {
+	lectureInputs, lectureOut := lecture()
+	p := newMLPProblem(4, []int{4})
+	mlpOut, mlpInputs := p.loss()
+	x := NewValue(2)
+	y := NewValue(3)
+
+	shift := func(values []*Value, by float64) []float64 {
+		data := leafData(values)
+		for i := range data {
+			data[i] += by
+		}
+		return data
+	}
+	funcs := []generated{
+		{"lecture", compile(t, lectureOut, lectureInputs...), [][]float64{{-4, 2}, {3, -1.5}}},
+		{"mlp", compile(t, mlpOut, mlpInputs...), [][]float64{leafData(mlpInputs), shift(mlpInputs, 0.1)}},
+		{"identity", compile(t, x.Neg().Neg(), x, y), [][]float64{{2, 3}}},
+		{"constant", compile(t, NewValue(2).Exp(), x), [][]float64{{1}}},
+	}
+
+	outs, grads := runGenerated(t, funcs)
+	for i, f := range funcs {
+		want := make([]float64, f.tape.NumInputs())
+		for j, in := range f.inputs {
+			assert.InDelta(t, f.tape.Forward(in), outs[i][j], 1e-12, "%s output at point %d", f.name, j)
+			f.tape.Backward()
+			f.tape.Grads(want)
+			assert.InDeltaSlice(t, want, grads[i][j], 1e-12, "%s gradients at point %d", f.name, j)
+		}
+	}
+
+	// Check the tapes themselves against Backward at the original point.
+	lectureOut.Backward()
+	assert.InDelta(t, lectureInputs[0].grad, grads[0][0][0], 1e-12)
+	assert.InDelta(t, lectureInputs[1].grad, grads[0][0][1], 1e-12)
+	mlpOut.Backward()
+	for i, in := range mlpInputs {
+		assert.InDelta(t, in.grad, grads[1][0][i], 1e-12, "mlp input %d", i)
+	}
+}
+
+func TestWriteGoRejectsFunctions(t *testing.T) {
+	a := NewValue(1)
+	tape := compile(t, Apply(sigmoid{}, a), a)
+	assert.ErrorContains(t, tape.WriteGo(&bytes.Buffer{}, "main", "f"), "sigmoid")
+}
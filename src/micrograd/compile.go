@@ -0,0 +1,255 @@
+package micrograd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type opcode uint8
+
+const (
+	opAdd opcode = iota
+	opAddConst
+	opMul
+	opPow
+	opReLU
+	opExp
+	opLog
+	opFunc
+)
+
+func (op opcode) binary() bool {
+	return op <= opPow
+}
+
+// evalOp computes a built-in op. Unary ops ignore y.
+func evalOp(op opcode, x, y float64) float64 {
+	switch op {
+	case opAdd, opAddConst:
+		return x + y
+	case opMul:
+		return x * y
+	case opPow:
+		return math.Pow(x, y)
+	case opReLU:
+		return math.Max(0, x)
+	case opExp:
+		return math.Exp(x)
+	case opLog:
+		return math.Log(x)
+	}
+
+	panic(fmt.Sprintf("micrograd: no evaluation for opcode %d", op))
+}
+
+// instr computes slot out from slots a and b. Unary ops ignore b. For opFunc
+// the inputs are args[a : a+b] and fn indexes Tape.fns.
+type instr struct {
+	op   opcode
+	out  int32
+	a, b int32
+	fn   int32
+}
+
+// Tape is a Value graph flattened into a list of instructions over one
+// float64 slice, so it can be run again with new inputs without building
+// nodes or calling closures. Every node gets a slot; leaves that are not
+// inputs keep the data they had when the graph was compiled.
+//
+// A Tape is not safe for concurrent use.
+type Tape struct {
+	code   []instr
+	data   []float64
+	grad   []float64
+	inputs []int32
+	output int32
+	fns    []Function
+	args   []int32
+	in     []float64 // scratch space for Function inputs
+}
+
+// Compile records the graph behind root as a Tape taking inputs, which must
+// be leaves. Inputs root does not depend on are allowed and always get a
+// zero gradient.
+func Compile(root *Value, inputs []*Value) (*Tape, error) {
+	nodes := root.topo()
+	slots := make(map[*Value]int32, len(nodes)+len(inputs))
+	t := &Tape{}
+
+	slot := func(v *Value) int32 {
+		if s, ok := slots[v]; ok {
+			return s
+		}
+		s := int32(len(t.data))
+		slots[v] = s
+		t.data = append(t.data, v.data)
+		return s
+	}
+
+	for _, in := range inputs {
+		if len(in.children) > 0 {
+			return nil, fmt.Errorf("compile: input %v is computed by %q, not a leaf", in, in.op)
+		}
+		if _, dup := slots[in]; dup {
+			return nil, fmt.Errorf("compile: input %v is listed twice", in)
+		}
+		t.inputs = append(t.inputs, slot(in))
+	}
+
+	for _, v := range nodes {
+		out := slot(v)
+		if len(v.children) == 0 {
+			continue
+		}
+
+		c := v.children
+		ins := instr{out: out, a: slot(c[0])}
+		if len(c) > 1 {
+			ins.b = slot(c[1])
+		}
+		switch {
+		case v.fn != nil:
+			ins = instr{op: opFunc, out: out, a: int32(len(t.args)), b: int32(len(c)), fn: int32(len(t.fns))}
+			for _, child := range c {
+				t.args = append(t.args, slot(child))
+			}
+			t.fns = append(t.fns, v.fn)
+			if len(c) > len(t.in) {
+				t.in = make([]float64, len(c))
+			}
+		case v.op == "+":
+			ins.op = opAdd
+		case v.op == "+scalar":
+			ins.op = opAddConst
+		case v.op == "*":
+			ins.op = opMul
+		case strings.HasPrefix(v.op, "**"):
+			ins.op = opPow
+		case v.op == "relu":
+			ins.op = opReLU
+		case v.op == "exp":
+			ins.op = opExp
+		case v.op == "log":
+			ins.op = opLog
+		default:
+			return nil, fmt.Errorf("compile: unknown op %q", v.op)
+		}
+		t.code = append(t.code, ins)
+	}
+
+	t.output = slots[root]
+	t.grad = make([]float64, len(t.data))
+	return t, nil
+}
+
+// Forward loads inputs, in the order given to Compile, and returns the
+// recomputed output.
+func (t *Tape) Forward(inputs []float64) float64 {
+	if len(inputs) != len(t.inputs) {
+		panic(fmt.Sprintf("micrograd: tape takes %d inputs, got %d", len(t.inputs), len(inputs)))
+	}
+	for i, s := range t.inputs {
+		t.data[s] = inputs[i]
+	}
+
+	d := t.data
+	for _, ins := range t.code {
+		if ins.op == opFunc {
+			d[ins.out] = t.fns[ins.fn].Forward(t.fnInputs(ins))
+		} else {
+			d[ins.out] = evalOp(ins.op, d[ins.a], d[ins.b])
+		}
+	}
+
+	return d[t.output]
+}
+
+// Backward computes the gradient of the output with respect to every slot,
+// using the data from the last Forward. Unlike Value.Backward, gradients
+// are reset on each call rather than accumulated.
+func (t *Tape) Backward() {
+	clear(t.grad)
+	t.grad[t.output] = 1
+
+	d, g := t.data, t.grad
+	for i := len(t.code) - 1; i >= 0; i-- {
+		ins := t.code[i]
+		gout := g[ins.out]
+		switch ins.op {
+		case opAdd:
+			g[ins.a] += gout
+			g[ins.b] += gout
+		case opAddConst:
+			g[ins.a] += gout
+		case opMul:
+			g[ins.a] += d[ins.b] * gout
+			g[ins.b] += d[ins.a] * gout
+		case opPow:
+			// Same guards as Value.Pow
+			base, exp := d[ins.a], d[ins.b]
+			if base != 0 {
+				g[ins.a] += exp * math.Pow(base, exp-1) * gout
+			}
+			if base > 0 {
+				g[ins.b] += math.Log(base) * d[ins.out] * gout
+			}
+		case opReLU:
+			if d[ins.a] > 0 {
+				g[ins.a] += gout
+			}
+		case opExp:
+			g[ins.a] += d[ins.out] * gout
+		case opLog:
+			g[ins.a] += gout / d[ins.a]
+		case opFunc:
+			grads := t.fns[ins.fn].Backward(t.fnInputs(ins), d[ins.out], gout)
+			if len(grads) != int(ins.b) {
+				panic(fmt.Sprintf("micrograd: %s returned %d gradients for %d inputs", FunctionName(t.fns[ins.fn]), len(grads), ins.b))
+			}
+			for j, s := range t.args[ins.a : ins.a+ins.b] {
+				g[s] += grads[j]
+			}
+		}
+	}
+}
+
+func (t *Tape) fnInputs(ins instr) []float64 {
+	in := t.in[:ins.b]
+	for j, s := range t.args[ins.a : ins.a+ins.b] {
+		in[j] = t.data[s]
+	}
+
+	return in
+}
+
+// Output returns the output computed by the last Forward.
+func (t *Tape) Output() float64 {
+	return t.data[t.output]
+}
+
+// Grad returns the gradient of input i from the last Backward.
+func (t *Tape) Grad(i int) float64 {
+	return t.grad[t.inputs[i]]
+}
+
+// Grads copies the input gradients from the last Backward into dst, which
+// must have one entry per input.
+func (t *Tape) Grads(dst []float64) {
+	if len(dst) != len(t.inputs) {
+		panic(fmt.Sprintf("micrograd: tape has %d inputs, got %d gradient slots", len(t.inputs), len(dst)))
+	}
+	for i, s := range t.inputs {
+		dst[i] = t.grad[s]
+	}
+}
+
+// NumInputs returns the number of inputs Forward takes.
+func (t *Tape) NumInputs() int {
+	return len(t.inputs)
+}
+
+// Len returns the number of instructions on the tape.
+func (t *Tape) Len() int {
+	return len(t.code)
+}
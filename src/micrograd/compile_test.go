@@ -0,0 +1,178 @@
+package micrograd
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mlpProblem is a hinge loss over a batch of 2D points for a small MLP, the
+// shape of the classify command's training step.
+type mlpProblem struct {
+	mlp    *MLP
+	points [][2]float64
+	labels []float64
+}
+
+func newMLPProblem(batch int, hidden []int) *mlpProblem {
+	rng := rand.New(rand.NewSource(42))
+	p := &mlpProblem{mlp: NewMLP(2, append(hidden, 1), rng)}
+	for i := 0; i < batch; i++ {
+		x, y := rng.Float64()*2-1, rng.Float64()*2-1
+		p.points = append(p.points, [2]float64{x, y})
+		p.labels = append(p.labels, math.Copysign(1, x*y))
+	}
+
+	return p
+}
+
+// loss builds the graph and returns it with its leaves: the parameters
+// followed by the point coordinates.
+func (p *mlpProblem) loss() (*Value, []*Value) {
+	inputs := p.mlp.Parameters()
+	var scores []*Value
+	for _, pt := range p.points {
+		x := []*Value{NewValue(pt[0]), NewValue(pt[1])}
+		inputs = append(inputs, x...)
+		scores = append(scores, p.mlp.Call(x)[0])
+	}
+
+	return Hinge(scores, p.labels).Add(L2(p.mlp.Parameters(), 1e-4)), inputs
+}
+
+func leafData(values []*Value) []float64 {
+	data := make([]float64, len(values))
+	for i, v := range values {
+		data[i] = v.data
+	}
+
+	return data
+}
+
+func TestTapeMatchesBackward(t *testing.T) {
+	inputs, out := lecture()
+	tape, err := Compile(out, inputs)
+	require.NoError(t, err)
+
+	out.Backward()
+	assert.InDelta(t, out.data, tape.Forward(leafData(inputs)), 1e-12)
+	tape.Backward()
+	for i, in := range inputs {
+		assert.InDelta(t, in.grad, tape.Grad(i), 1e-12, "input %d", i)
+	}
+}
+
+func TestTapeNewInputs(t *testing.T) {
+	inputs, out := lecture()
+	tape, err := Compile(out, inputs)
+	require.NoError(t, err)
+
+	for _, x := range [][]float64{{3, -1.5}, {0.5, 0.25}, {-4, 2}} {
+		fresh, want := lecture()
+		fresh[0].SetData(x[0])
+		fresh[1].SetData(x[1])
+		NewGraph(want).Forward()
+		want.Backward()
+
+		assert.InDelta(t, want.data, tape.Forward(x), 1e-12)
+		tape.Backward()
+		grads := make([]float64, 2)
+		tape.Grads(grads)
+		assert.InDelta(t, fresh[0].grad, grads[0], 1e-9)
+		assert.InDelta(t, fresh[1].grad, grads[1], 1e-9)
+	}
+}
+
+func TestTapeMLP(t *testing.T) {
+	p := newMLPProblem(8, []int{8, 8})
+	out, inputs := p.loss()
+	tape, err := Compile(out, inputs)
+	require.NoError(t, err)
+
+	x := leafData(inputs)
+	tape.Forward(x)
+	tape.Backward()
+	ZeroGrad(p.mlp)
+	out.Backward()
+	for i, in := range inputs {
+		require.InDelta(t, in.grad, tape.Grad(i), 1e-12, "input %d", i)
+	}
+}
+
+func TestTapeFunction(t *testing.T) {
+	a, b := NewValue(0.6), NewValue(-0.8)
+	out := Apply(hypot{}, a, b.MulScalar(3)).Add(Apply(sigmoid{}, a))
+	out.Backward()
+
+	tape, err := Compile(out, []*Value{a, b})
+	require.NoError(t, err)
+	assert.InDelta(t, out.data, tape.Forward([]float64{0.6, -0.8}), 1e-12)
+	tape.Backward()
+	assert.InDelta(t, a.grad, tape.Grad(0), 1e-12)
+	assert.InDelta(t, b.grad, tape.Grad(1), 1e-12)
+}
+
+func TestTapeDoesNotAllocate(t *testing.T) {
+	p := newMLPProblem(4, []int{8})
+	out, inputs := p.loss()
+	tape, err := Compile(out, inputs)
+	require.NoError(t, err)
+
+	x := leafData(inputs)
+	grads := make([]float64, len(x))
+	allocs := testing.AllocsPerRun(10, func() {
+		tape.Forward(x)
+		tape.Backward()
+		tape.Grads(grads)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestCompileErrors(t *testing.T) {
+	a := NewValue(1)
+	b := a.MulScalar(2)
+
+	_, err := Compile(b.Exp(), []*Value{b})
+	assert.ErrorContains(t, err, "not a leaf")
+	_, err = Compile(b, []*Value{a, a})
+	assert.ErrorContains(t, err, "twice")
+
+	// Unused inputs are fine and have no gradient.
+	unused := NewValue(5)
+	tape, err := Compile(b, []*Value{a, unused})
+	require.NoError(t, err)
+	tape.Forward([]float64{3, 5})
+	tape.Backward()
+	assert.Equal(t, 6.0, tape.Output())
+	assert.Equal(t, 2.0, tape.Grad(0))
+	assert.Zero(t, tape.Grad(1))
+	assert.Panics(t, func() { tape.Forward([]float64{1}) })
+}
+
+func BenchmarkMLPValueBackward(b *testing.B) {
+	p := newMLPProblem(32, []int{16, 16})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out, _ := p.loss()
+		ZeroGrad(p.mlp)
+		out.Backward()
+	}
+}
+
+func BenchmarkMLPTapeBackward(b *testing.B) {
+	p := newMLPProblem(32, []int{16, 16})
+	out, inputs := p.loss()
+	tape, err := Compile(out, inputs)
+	require.NoError(b, err)
+	x := leafData(inputs)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tape.Forward(x)
+		tape.Backward()
+	}
+}
@@ -0,0 +1,163 @@
+package micrograd
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Graphs are not safe for concurrent use: ops read their inputs' data and
+// Backward adds into every node's grad without locking. Building graphs on
+// several goroutines is safe as long as no node, parameters included, is
+// shared between the graphs that are differentiated concurrently.
+//
+// To train shared parameters from several goroutines, give each goroutine
+// its own replica (MLP.Clone), run Backward on the replica's graph and,
+// once every goroutine is done, sum the replica gradients into the shared
+// parameters with ReduceGrads. After the optimizer steps, copy the new data
+// back with CopyData.
+//
+// NoGrad and DetectAnomaly switch process wide modes, so they should not
+// change while other goroutines build graphs.
+
+// ReduceGrads adds the gradients of each replica to the matching entry of
+// params. Replicas are summed in the order given, so the result does not
+// depend on which goroutine finished first.
+func ReduceGrads(params []*Value, replicas ...[]*Value) {
+	for _, r := range replicas {
+		if len(r) != len(params) {
+			panic(fmt.Sprintf("micrograd: replica has %d parameters, want %d", len(r), len(params)))
+		}
+	}
+
+	for i, p := range params {
+		for _, r := range replicas {
+			p.grad += r[i].grad
+		}
+	}
+}
+
+// CopyData sets the data of each dst value to that of the matching src.
+func CopyData(dst, src []*Value) {
+	if len(dst) != len(src) {
+		panic(fmt.Sprintf("micrograd: copying %d values into %d", len(src), len(dst)))
+	}
+	for i, v := range src {
+		dst[i].data = v.data
+	}
+}
+
+// BackwardParallel is Backward with the graph's nodes spread over workers
+// goroutines, or GOMAXPROCS when workers is not positive. A node runs as
+// soon as every node using it has, so independent branches such as the
+// examples of a batch run side by side. Gradients are the same as
+// Backward's up to the order floating point sums are taken in.
+//
+// Scheduling costs far more than a scalar op, so this only pays off when
+// the graph is wide and the ops are Functions doing real work.
+func (v *Value) BackwardParallel(workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	topo := v.topo()
+	index := make(map[*Value]int32, len(topo))
+	for i, node := range topo {
+		index[node] = int32(i)
+	}
+
+	// waiting counts the uses of each node whose backward has not run yet
+	waiting := make([]atomic.Int32, len(topo))
+	for _, node := range topo {
+		for _, c := range node.children {
+			waiting[index[c]].Add(1)
+		}
+	}
+	locks := make([]sync.Mutex, len(topo))
+
+	v.grad = 1.0
+	ready := make(chan int32, len(topo))
+	ready <- index[v]
+
+	var (
+		wg       sync.WaitGroup
+		failed   atomic.Bool
+		panicked any
+		once     sync.Once
+	)
+	wg.Add(len(topo))
+
+	run := func(node *Value, children []int32) {
+		defer func() {
+			if r := recover(); r != nil {
+				once.Do(func() { panicked = r })
+				failed.Store(true)
+			}
+		}()
+
+		// Parents sharing a child may run at once, so hold the children's
+		// locks while their gradients are updated. Children always come
+		// before their parents in topo, so locking in index order cannot
+		// deadlock.
+		for _, c := range children {
+			locks[c].Lock()
+		}
+		defer func() {
+			for _, c := range children {
+				locks[c].Unlock()
+			}
+		}()
+
+		node.backward()
+		if anomalyEnabled() {
+			node.checkGrads()
+		}
+	}
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			var children []int32
+			for i := range ready {
+				node := topo[i]
+				children = children[:0]
+				for _, c := range node.children {
+					children = appendSorted(children, index[c])
+				}
+
+				// After a panic the rest of the graph is drained without
+				// running, so the wait below still finishes.
+				if !failed.Load() {
+					run(node, children)
+				}
+				for _, c := range node.children {
+					if waiting[index[c]].Add(-1) == 0 {
+						ready <- index[c]
+					}
+				}
+				wg.Done()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(ready)
+	if panicked != nil {
+		panic(panicked)
+	}
+}
+
+// appendSorted inserts x into the sorted slice s unless it is already there.
+func appendSorted(s []int32, x int32) []int32 {
+	i := 0
+	for i < len(s) && s[i] < x {
+		i++
+	}
+	if i < len(s) && s[i] == x {
+		return s
+	}
+	s = append(s, 0)
+	copy(s[i+1:], s[i:])
+	s[i] = x
+	return s
+}
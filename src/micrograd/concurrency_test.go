@@ -0,0 +1,136 @@
+package micrograd
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shardLoss is the summed hinge loss of mlp over points[lo:hi], so shard
+// losses add up to the full batch loss.
+func (p *mlpProblem) shardLoss(mlp *MLP, lo, hi int) *Value {
+	var losses []*Value
+	for i := lo; i < hi; i++ {
+		x := []*Value{NewValue(p.points[i][0]), NewValue(p.points[i][1])}
+		score := mlp.Call(x)[0]
+		losses = append(losses, score.MulScalar(-p.labels[i]).AddScalar(1).ReLU())
+	}
+
+	return Sum(losses)
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	p := newMLPProblem(1, []int{4})
+	clone := p.mlp.Clone()
+	params, cloned := p.mlp.Parameters(), clone.Parameters()
+	require.Len(t, cloned, len(params))
+	for i := range params {
+		assert.Equal(t, params[i].Data(), cloned[i].Data())
+		assert.NotSame(t, params[i], cloned[i])
+	}
+
+	cloned[0].SetData(100)
+	assert.NotEqual(t, 100.0, params[0].Data())
+	CopyData(params, cloned)
+	assert.Equal(t, 100.0, params[0].Data())
+}
+
+func TestReplicasMatchSingleGraph(t *testing.T) {
+	const workers = 4
+	p := newMLPProblem(32, []int{8, 8})
+	params := p.mlp.Parameters()
+
+	whole := p.shardLoss(p.mlp, 0, len(p.points))
+	whole.Backward()
+	want := make([]float64, len(params))
+	for i, v := range params {
+		want[i] = v.grad
+	}
+	ZeroGrad(p.mlp)
+
+	replicas := make([][]*Value, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		replica := p.mlp.Clone()
+		replicas[w] = replica.Parameters()
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			size := len(p.points) / workers
+			p.shardLoss(replica, w*size, (w+1)*size).Backward()
+		}(w)
+	}
+	wg.Wait()
+
+	ReduceGrads(params, replicas...)
+	for i, v := range params {
+		assert.InDelta(t, want[i], v.grad, 1e-12, "param %d", i)
+	}
+	assert.Panics(t, func() { ReduceGrads(params, replicas[0][1:]) })
+}
+
+func TestBackwardParallel(t *testing.T) {
+	p := newMLPProblem(16, []int{8, 8})
+	out, inputs := p.loss()
+	out.Backward()
+	want := leafGrads(inputs)
+
+	for _, workers := range []int{1, 3, 0} {
+		for _, v := range NewGraph(out).Nodes {
+			v.grad = 0
+		}
+		out.BackwardParallel(workers)
+		assert.InDeltaSlice(t, want, leafGrads(inputs), 1e-12, "%d workers", workers)
+	}
+}
+
+func TestBackwardParallelSharedChildren(t *testing.T) {
+	// Every product uses x twice and every sum uses every product, so many
+	// parents update the same children.
+	x := NewValue(1.5)
+	var terms []*Value
+	for i := 0; i < 50; i++ {
+		terms = append(terms, x.Multiply(x).MulScalar(float64(i)))
+	}
+	out := Sum(terms).Add(Sum(terms))
+
+	out.BackwardParallel(8)
+	assert.InDelta(t, 2*2*1.5*float64(49*50/2), x.grad, 1e-9)
+}
+
+// sqrtFn has an infinite gradient at 0, which only shows up in backward.
+type sqrtFn struct{}
+
+func (sqrtFn) Forward(in []float64) float64 { return math.Sqrt(in[0]) }
+
+func (sqrtFn) Backward(_ []float64, out, gradOut float64) []float64 {
+	return []float64{gradOut / (2 * out)}
+}
+
+func TestBackwardParallelAnomaly(t *testing.T) {
+	x := NewValue(0)
+	err := DetectAnomaly(func() {
+		var terms []*Value
+		for i := 0; i < 20; i++ {
+			terms = append(terms, x.MulScalar(float64(i)).Exp())
+		}
+		Sum(append(terms, Apply(sqrtFn{}, x))).BackwardParallel(4)
+	})
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "backward", anomaly.Phase)
+	assert.Equal(t, "micrograd.sqrtFn", anomaly.Op)
+}
+
+func leafGrads(values []*Value) []float64 {
+	grads := make([]float64, len(values))
+	for i, v := range values {
+		grads[i] = v.grad
+	}
+
+	return grads
+}
@@ -0,0 +1,48 @@
+package micrograd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDot writes the graph behind root in graphviz DOT form: a record per
+// value showing its label, data and grad, and an op node between every
+// computed value and its inputs.
+func WriteDot(w io.Writer, root *Value) error {
+	var b strings.Builder
+	b.WriteString("digraph micrograd {\n\trankdir=LR;\n\tnode [shape=record];\n")
+
+	topo := root.topo()
+	ids := make(map[*Value]int, len(topo))
+	for i, v := range topo {
+		ids[v] = i
+	}
+
+	for i, v := range topo {
+		label := fmt.Sprintf("data %.4f | grad %.4f", v.data, v.grad)
+		if v.label != "" {
+			label = dotEscape(v.label) + " | " + label
+		}
+		fmt.Fprintf(&b, "\tv%d [label=\"{ %s }\"];\n", i, label)
+		if len(v.children) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\top%d [label=%s, shape=ellipse];\n", i, strconv.Quote(v.opString()))
+		fmt.Fprintf(&b, "\top%d -> v%d;\n", i, i)
+		for _, c := range v.children {
+			fmt.Fprintf(&b, "\tv%d -> op%d;\n", ids[c], i)
+		}
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotEscape escapes the characters that are special in record labels.
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace(s)
+}
@@ -0,0 +1,94 @@
+package micrograd
+
+import "math"
+
+// Dual is a forward mode number: Val carries the value and Tan its
+// derivative along one input direction. Every op mirrors the Value op of
+// the same name, so an expression written for Value computes a
+// Jacobian-vector product when run on Duals, in a single pass and without
+// building a graph. That beats Backward when there are few inputs and many
+// outputs.
+type Dual struct {
+	Val float64
+	Tan float64
+}
+
+func NewDual(val, tan float64) Dual {
+	return Dual{Val: val, Tan: tan}
+}
+
+// Constant is a Dual with no tangent.
+func Constant(val float64) Dual {
+	return Dual{Val: val}
+}
+
+func (d Dual) Add(other Dual) Dual {
+	return Dual{d.Val + other.Val, d.Tan + other.Tan}
+}
+
+func (d Dual) AddScalar(scalar float64) Dual {
+	return Dual{d.Val + scalar, d.Tan}
+}
+
+func (d Dual) Multiply(other Dual) Dual {
+	return Dual{d.Val * other.Val, d.Tan*other.Val + d.Val*other.Tan}
+}
+
+func (d Dual) MulScalar(scalar float64) Dual {
+	return Dual{d.Val * scalar, d.Tan * scalar}
+}
+
+// Pow skips the same undefined terms as Value.Pow's backward: the base term
+// when the base is 0 and the exponent term when ln(base) is undefined.
+func (d Dual) Pow(exp Dual) Dual {
+	out := Dual{Val: math.Pow(d.Val, exp.Val)}
+	if d.Val != 0 {
+		out.Tan += exp.Val * math.Pow(d.Val, exp.Val-1) * d.Tan
+	}
+	if d.Val > 0 {
+		out.Tan += math.Log(d.Val) * out.Val * exp.Tan
+	}
+
+	return out
+}
+
+func (d Dual) PowScalar(scalar float64) Dual {
+	return d.Pow(Constant(scalar))
+}
+
+func (d Dual) ReLU() Dual {
+	if d.Val > 0 {
+		return d
+	}
+
+	return Dual{}
+}
+
+func (d Dual) Exp() Dual {
+	e := math.Exp(d.Val)
+	return Dual{e, e * d.Tan}
+}
+
+func (d Dual) Log() Dual {
+	return Dual{math.Log(d.Val), d.Tan / d.Val}
+}
+
+func (d Dual) Neg() Dual {
+	return d.MulScalar(-1)
+}
+
+func (d Dual) Sub(other Dual) Dual {
+	return d.Add(other.Neg())
+}
+
+func (d Dual) SubScalar(scalar float64) Dual {
+	return d.AddScalar(-scalar)
+}
+
+func (d Dual) Div(other Dual) Dual {
+	return d.Multiply(other.PowScalar(-1))
+}
+
+func (d Dual) DivScalar(scalar float64) Dual {
+	return d.MulScalar(1 / scalar)
+}
@@ -0,0 +1,135 @@
+package micrograd
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scalar is the op set Value and Dual share, so test expressions can be
+// written once and run in both modes.
+type scalar[T any] interface {
+	Add(T) T
+	AddScalar(float64) T
+	Multiply(T) T
+	MulScalar(float64) T
+	Pow(T) T
+	PowScalar(float64) T
+	ReLU() T
+	Exp() T
+	Log() T
+	Neg() T
+	Sub(T) T
+	SubScalar(float64) T
+	Div(T) T
+	DivScalar(float64) T
+}
+
+// The expressions below are the ones from the engine tests.
+
+func sanityCheck[T scalar[T]](in []T, _ func(float64) T) T {
+	x := in[0]
+	z := x.MulScalar(2).AddScalar(2).Add(x)
+	q := z.ReLU().Add(z.Multiply(x))
+	h := z.Multiply(z).ReLU()
+	return h.Add(q).Add(q.Multiply(x))
+}
+
+func moreOps[T scalar[T]](in []T, lift func(float64) T) T {
+	a, b := in[0], in[1]
+	c := a.Add(b)
+	d := a.Multiply(b).Add(b.PowScalar(3))
+	c = c.Add(c).AddScalar(1)
+	c = c.AddScalar(1).Add(c).Add(a.Neg())
+	d = d.Add((d.MulScalar(2)).Add(b.Add(a)).ReLU())
+	d = d.Add((d.MulScalar(3)).Add(b.Sub(a)).ReLU())
+	e := c.Sub(d)
+	f := e.PowScalar(2)
+	g := f.DivScalar(2.0)
+	return g.Add(lift(10.0).Div(f))
+}
+
+func combinedOperation[T scalar[T]](in []T, _ func(float64) T) T {
+	a, b := in[0], in[1]
+	c := a.Add(b).AddScalar(1)
+	return c.Add(c.AddScalar(1).Add(c).Add(a.Neg()))
+}
+
+func overAccumulation[T scalar[T]](in []T, _ func(float64) T) T {
+	c := in[0].Add(in[1]).AddScalar(1)
+	return c.Add(c.AddScalar(1))
+}
+
+func complexOperation[T scalar[T]](in []T, _ func(float64) T) T {
+	a, b := in[0], in[1]
+	d := a.Multiply(b).Add(b.PowScalar(3))
+	return d.Add(d.MulScalar(2)).Add(b.Add(a).ReLU())
+}
+
+func backwardChain[T scalar[T]](in []T, _ func(float64) T) T {
+	v1, v2 := in[0], in[1]
+	return v1.Add(v2).Multiply(v1).PowScalar(2.0)
+}
+
+func powExpLog[T scalar[T]](in []T, _ func(float64) T) T {
+	a, exp := in[0], in[1]
+	return a.Pow(exp).Add(a.Exp()).Add(a.Log()).Sub(exp.SubScalar(1).ReLU())
+}
+
+func negativeBase[T scalar[T]](in []T, _ func(float64) T) T {
+	return in[0].PowScalar(2.0).Add(in[0].PowScalar(3.0)).Add(in[1].ReLU())
+}
+
+type dualCase struct {
+	name    string
+	inputs  []float64
+	reverse func([]*Value, func(float64) *Value) *Value
+	forward func([]Dual, func(float64) Dual) Dual
+}
+
+var dualCases = []dualCase{
+	{"SanityCheck", []float64{-4}, sanityCheck[*Value], sanityCheck[Dual]},
+	{"MoreOps", []float64{-4, 2}, moreOps[*Value], moreOps[Dual]},
+	{"CombinedOperation", []float64{-4, 2}, combinedOperation[*Value], combinedOperation[Dual]},
+	{"OverAccumulation", []float64{-4, 2}, overAccumulation[*Value], overAccumulation[Dual]},
+	{"ComplexOperation", []float64{-4, 2}, complexOperation[*Value], complexOperation[Dual]},
+	{"Backward", []float64{2, 3}, backwardChain[*Value], backwardChain[Dual]},
+	{"PowExpLog", []float64{2, 3}, powExpLog[*Value], powExpLog[Dual]},
+	{"PowNegativeBase", []float64{-4, -1}, negativeBase[*Value], negativeBase[Dual]},
+}
+
+func TestDualAgreesWithBackward(t *testing.T) {
+	for _, tc := range dualCases {
+		t.Run(tc.name, func(t *testing.T) {
+			in := make([]*Value, len(tc.inputs))
+			for i, x := range tc.inputs {
+				in[i] = NewValue(x)
+			}
+			out := tc.reverse(in, NewValue)
+			out.Backward()
+
+			// One forward pass per input, seeding its tangent with 1
+			for i := range tc.inputs {
+				duals := make([]Dual, len(tc.inputs))
+				for j, x := range tc.inputs {
+					duals[j] = Constant(x)
+				}
+				duals[i].Tan = 1
+
+				d := tc.forward(duals, Constant)
+				assert.InDelta(t, out.data, d.Val, 1e-9, "Forward value mismatch")
+				assert.InDelta(t, in[i].grad, d.Tan, 1e-9, fmt.Sprintf("Derivative mismatch for input %d", i))
+			}
+		})
+	}
+}
+
+func TestDualDirectionalDerivative(t *testing.T) {
+	// f(x, y) = x·e^y along (1, 2) is e^y + 2x·e^y
+	x, y := 1.5, 0.5
+	d := NewDual(x, 1).Multiply(NewDual(y, 2).Exp())
+
+	assert.InDelta(t, math.Exp(y)+2*x*math.Exp(y), d.Tan, 1e-9)
+}
@@ -3,6 +3,7 @@ package micrograd
 import (
 	"fmt"
 	"math"
+	"strings"
 )
 
 type Value struct {
@@ -10,7 +11,10 @@ type Value struct {
 	grad     float64 // Gradient of the value
 	children []*Value
 	op       string
-	backward func() // Backpropagation function
+	backward func()    // Backpropagation function
+	stack    []uintptr // Creation call stack, recorded in anomaly mode
+	fn       Function  // Set for nodes made by Apply
+	label    string    // Name shown by Expr, LaTeX and WriteDot
 }
 
 func NewValue(data float64) *Value {
@@ -24,17 +28,29 @@ func NewValue(data float64) *Value {
 }
 
 func convertToValue(data float64, children []*Value, op string) *Value {
-	return &Value{
+	out := &Value{
 		data:     data,
 		grad:     0,
 		children: children,
 		op:       op,
 		backward: func() {},
 	}
+	if anomalyEnabled() {
+		out.recordStack()
+		if !finite(data) {
+			panic(out.anomaly("forward", "result is %g", data))
+		}
+	}
+
+	return out
 }
 
 func (v *Value) Add(other *Value) *Value {
-	out := convertToValue(v.data+other.data, []*Value{v, other}, "+")
+	data := v.data + other.data
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	out := convertToValue(data, []*Value{v, other}, "+")
 
 	out.backward = func() {
 		// Accumulate gradients proportionally
@@ -46,7 +62,17 @@ func (v *Value) Add(other *Value) *Value {
 }
 
 func (v *Value) AddScalar(scalar float64) *Value {
-	out := convertToValue(v.data+scalar, []*Value{v}, "+scalar")
+	if !GradEnabled() {
+		return NewValue(v.data + scalar)
+	}
+
+	return v.addScalar(convertToValue(scalar, nil, "scalar"))
+}
+
+// addScalar keeps the constant as a second child so the graph can be
+// re-evaluated from its nodes alone.
+func (v *Value) addScalar(scalar *Value) *Value {
+	out := convertToValue(v.data+scalar.data, []*Value{v, scalar}, "+scalar")
 
 	out.backward = func() {
 		v.grad += out.grad
@@ -57,7 +83,11 @@ func (v *Value) AddScalar(scalar float64) *Value {
 }
 
 func (v *Value) Multiply(other *Value) *Value {
-	out := convertToValue(v.data*other.data, []*Value{v, other}, "*")
+	data := v.data * other.data
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	out := convertToValue(data, []*Value{v, other}, "*")
 
 	// Define the backpropagation logic for multiplication
 	out.backward = func() {
@@ -72,8 +102,26 @@ func (v *Value) MulScalar(scalar float64) *Value {
 	return v.Multiply(convertToValue(scalar, nil, "scalar"))
 }
 
+// powOp is the op string of a power node, which shows its exponent.
+func powOp(exp float64) string {
+	return fmt.Sprintf("**%f", exp)
+}
+
+// opString is v's op with the exponent of a power node taken from its
+// current data, since Graph.Forward can change it after the node is made.
+func (v *Value) opString() string {
+	if strings.HasPrefix(v.op, "**") && len(v.children) == 2 {
+		return powOp(v.children[1].data)
+	}
+	return v.op
+}
+
 func (v *Value) Pow(exp *Value) *Value {
-	out := convertToValue(math.Pow(v.data, exp.data), []*Value{v, exp}, fmt.Sprintf("**%f", exp.data))
+	data := math.Pow(v.data, exp.data)
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	out := convertToValue(data, []*Value{v, exp}, powOp(exp.data))
 
 	out.backward = func() {
 		// Gradient with respect to the base (v)
@@ -82,11 +130,12 @@ func (v *Value) Pow(exp *Value) *Value {
 		}
 
 		// Gradient with respect to the exponent (exp)
-		// Skip computing ln(v) if v <= 0 to avoid undefined behavior
+		// Skip computing ln(v) if v <= 0 to avoid undefined behavior. That
+		// only loses information when the exponent is not a constant.
 		if v.data > 0 {
 			exp.grad += math.Log(v.data) * out.data * out.grad
-		} else {
-			fmt.Printf("Skipping ln(v) for v=%f <= 0 in Pow backward\n", v.data)
+		} else if anomalyEnabled() && exp.op != "scalar" {
+			panic(out.anomaly("backward", "ln(%g) is undefined, the exponent gets no gradient", v.data))
 		}
 	}
 
@@ -98,10 +147,14 @@ func (v *Value) PowScalar(scalar float64) *Value {
 }
 
 func (v *Value) ReLU() *Value {
-	out := convertToValue(0, []*Value{v}, "ReLU")
+	data := 0.0
 	if v.data > 0 {
-		out.data = v.data
+		data = v.data
+	}
+	if !GradEnabled() {
+		return NewValue(data)
 	}
+	out := convertToValue(data, []*Value{v}, "relu")
 
 	out.backward = func() {
 		if v.data > 0 {
@@ -112,11 +165,64 @@ func (v *Value) ReLU() *Value {
 	return out
 }
 
+func (v *Value) Exp() *Value {
+	data := math.Exp(v.data)
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	out := convertToValue(data, []*Value{v}, "exp")
+
+	out.backward = func() {
+		// d/dx e^x = e^x, which is already stored in out.data
+		v.grad += out.data * out.grad
+	}
+
+	return out
+}
+
+func (v *Value) Log() *Value {
+	data := math.Log(v.data)
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	out := convertToValue(data, []*Value{v}, "log")
+
+	out.backward = func() {
+		v.grad += out.grad / v.data
+	}
+
+	return out
+}
+
 func (v *Value) Backward() {
 	// Initialize the gradient of the root node
 	v.grad = 1.0
 
-	// Topological sorting to find all dependencies in the graph
+	topo := v.topo()
+
+	// Flag to prevent redundant executions
+	processed := make(map[*Value]bool)
+
+	// Backward pass
+	for i := len(topo) - 1; i >= 0; i-- {
+		node := topo[i]
+		if !processed[node] {
+			processed[node] = true
+			node.backward()
+			if anomalyEnabled() {
+				node.checkGrads()
+			}
+		}
+	}
+}
+
+// topo returns every node v depends on, children before their parents.
+func (v *Value) topo() []*Value {
+	return topoAll([]*Value{v})
+}
+
+// topoAll is topo over several roots, listing shared nodes once.
+func topoAll(roots []*Value) []*Value {
 	var topo []*Value
 	visited := make(map[*Value]bool)
 
@@ -132,26 +238,10 @@ func (v *Value) Backward() {
 		}
 	}
 
-	// Build the topological order starting from this node
-	buildTopo(v)
-
-	fmt.Println("Topological Order:")
-	for _, node := range topo {
-		fmt.Printf("Node: %s, data=%f, grad=%f\n", node.op, node.data, node.grad)
-	}
-
-	// Flag to prevent redundant executions
-	processed := make(map[*Value]bool)
-
-	// Backward pass
-	for i := len(topo) - 1; i >= 0; i-- {
-		node := topo[i]
-		if !processed[node] {
-			processed[node] = true
-			fmt.Printf("Processing Node: %s, data=%f, grad=%f\n", node.op, node.data, node.grad)
-			node.backward()
-		}
+	for _, root := range roots {
+		buildTopo(root)
 	}
+	return topo
 }
 
 func (v *Value) Neg() *Value {
@@ -174,6 +264,39 @@ func (v *Value) DivScalar(scalar float64) *Value {
 	return v.Div(convertToValue(scalar, nil, "scalar"))
 }
 
+// Detach returns a leaf holding v's data. Gradients do not flow through it
+// back into v's graph.
+func (v *Value) Detach() *Value {
+	return NewValue(v.data)
+}
+
+func (v *Value) Data() float64 {
+	return v.data
+}
+
+func (v *Value) SetData(data float64) {
+	v.data = data
+}
+
+func (v *Value) Grad() float64 {
+	return v.grad
+}
+
+func (v *Value) SetGrad(grad float64) {
+	v.grad = grad
+}
+
+func (v *Value) Label() string {
+	return v.label
+}
+
+// SetLabel names v in printed expressions and graph drawings. It returns v
+// so leaves can be labelled as they are created.
+func (v *Value) SetLabel(label string) *Value {
+	v.label = label
+	return v
+}
+
 func (v *Value) String() string {
 	return fmt.Sprintf("Value(data=%f, grad=%f, op='%s')", v.data, v.grad, v.op)
 }
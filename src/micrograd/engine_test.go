@@ -406,3 +406,25 @@ func TestComplexOperation(t *testing.T) {
 	// Compute expected gradients based on the computational graph.
 	fmt.Printf("a.grad = %f, b.grad = %f\n", a.grad, b.grad)
 }
+
+func TestExp(t *testing.T) {
+	a := NewValue(2.0)
+
+	b := a.Exp() // b = e^a
+	b.Backward()
+
+	// d(b)/d(a) = e^a
+	assert.InDelta(t, math.Exp(2.0), b.data, 1e-6, "Exp forward computation mismatch")
+	assert.InDelta(t, math.Exp(2.0), a.grad, 1e-6, "Exp backward gradient mismatch for a")
+}
+
+func TestLog(t *testing.T) {
+	a := NewValue(4.0)
+
+	b := a.Log() // b = ln(a)
+	b.Backward()
+
+	// d(b)/d(a) = 1 / a
+	assert.InDelta(t, math.Log(4.0), b.data, 1e-6, "Log forward computation mismatch")
+	assert.InDelta(t, 0.25, a.grad, 1e-6, "Log backward gradient mismatch for a")
+}
@@ -0,0 +1,384 @@
+package micrograd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr renders the graph behind root as infix arithmetic, e.g.
+//
+//	t1 = a * b
+//	L = exp(t1) + t1 / c
+//
+// Labelled leaves print as their label and other leaves as numbers;
+// subexpressions with no labelled leaves are shown by their value.
+// Subexpressions used more than once are bound to t1, t2, ... and labelled
+// ones to their label, one binding per line before the result.
+func Expr(root *Value) string {
+	p := newPrinter(false, "", root)
+	var lines []string
+	for _, v := range p.bound {
+		lines = append(lines, p.names[v]+" = "+p.define(v))
+	}
+	if _, ok := p.names[root]; !ok {
+		lines = append(lines, p.define(root))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// LaTeX renders root and its derivative with respect to each labelled leaf
+// as a LaTeX aligned block, binding shared subexpressions as Expr does. The
+// derivatives come from Grad, so ReLU and the guards in Pow are resolved at
+// the current data: relu'(x) shows as 1 or 0, not a step function.
+func LaTeX(root *Value) string {
+	var inputs []*Value
+	for _, v := range root.topo() {
+		if len(v.children) == 0 && v.label != "" {
+			inputs = append(inputs, v)
+		}
+	}
+	grads := Grad(root, inputs, true)
+
+	name := root.label
+	if name == "" {
+		name = "f"
+	}
+	p := newPrinter(true, name, append([]*Value{root}, grads...)...)
+
+	var b strings.Builder
+	b.WriteString("\\begin{aligned}\n")
+	for _, v := range p.bound {
+		fmt.Fprintf(&b, "%s &= %s \\\\\n", p.names[v], p.define(v))
+	}
+	for i, in := range inputs {
+		fmt.Fprintf(&b, "\\frac{\\partial %s}{\\partial %s} &= %s", p.names[root], in.label, p.render(grads[i], precSum))
+		if i < len(inputs)-1 {
+			b.WriteString(" \\\\")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\\end{aligned}")
+
+	return b.String()
+}
+
+// Operator precedence, loosest first
+const (
+	precSum = iota + 1
+	precProd
+	precPow
+	precAtom
+)
+
+type printer struct {
+	latex    bool
+	names    map[*Value]string
+	bound    []*Value // named computed nodes, inputs before users
+	constant map[*Value]bool
+}
+
+// newPrinter names the nodes behind roots. A non-empty rootName always
+// binds the first root under that name, unless it is labelled.
+func newPrinter(latex bool, rootName string, roots ...*Value) *printer {
+	p := &printer{latex: latex, names: map[*Value]string{}, constant: map[*Value]bool{}}
+	nodes := topoAll(roots)
+
+	uses := make(map[*Value]int)
+	for _, v := range nodes {
+		p.constant[v] = v.label == ""
+		for _, c := range v.children {
+			uses[c]++
+			p.constant[v] = p.constant[v] && p.constant[c]
+		}
+	}
+	// -x and 1/x print as part of their user, so a shared one is not named
+	// but makes x shared instead.
+	for i := len(nodes) - 1; i >= 0; i-- {
+		v := nodes[i]
+		if x, ok := p.wraps(v); ok && uses[v] > 1 && v.label == "" {
+			uses[x] += uses[v] - 1
+			uses[v] = 1
+		}
+	}
+
+	temps := 0
+	for _, v := range nodes {
+		if len(v.children) == 0 {
+			if v.label != "" {
+				p.names[v] = v.label
+			}
+			continue
+		}
+		switch {
+		case v.label != "":
+			p.names[v] = v.label
+		case v == roots[0] && rootName != "":
+			p.names[v] = rootName
+		case p.constant[v] || p.hidden(v):
+			continue
+		case uses[v] > 1:
+			temps++
+			p.names[v] = fmt.Sprintf("t%d", temps)
+			if latex {
+				p.names[v] = fmt.Sprintf("t_{%d}", temps)
+			}
+		default:
+			continue
+		}
+		p.bound = append(p.bound, v)
+	}
+
+	return p
+}
+
+// hidden reports whether v only wraps another node, so naming it would
+// just rename that node.
+func (p *printer) hidden(v *Value) bool {
+	if len(v.children) != 2 || v.fn != nil {
+		return false
+	}
+	a, b := v.children[0], v.children[1]
+	switch {
+	case v.op == "*":
+		return p.isConst(a, 1) || p.isConst(b, 1)
+	case v.op == "+", v.op == "+scalar":
+		return p.isConst(a, 0) || p.isConst(b, 0)
+	case strings.HasPrefix(v.op, "**"):
+		return p.isConst(b, 1)
+	}
+
+	return false
+}
+
+func (p *printer) isConst(v *Value, x float64) bool {
+	return p.constant[v] && v.data == x
+}
+
+// render returns v as an expression that can stand as an operand needing
+// at least precedence prec.
+func (p *printer) render(v *Value, prec int) string {
+	if name, ok := p.names[v]; ok {
+		return name
+	}
+
+	s, got := p.expr(v)
+	if got < prec {
+		if p.latex {
+			return "\\left(" + s + "\\right)"
+		}
+		return "(" + s + ")"
+	}
+
+	return s
+}
+
+// operand is v by name if it has one, otherwise as an expression.
+func (p *printer) operand(v *Value) (string, int) {
+	if name, ok := p.names[v]; ok {
+		return name, precAtom
+	}
+
+	return p.expr(v)
+}
+
+// define renders v's own expression, ignoring any name it has.
+func (p *printer) define(v *Value) string {
+	s, _ := p.expr(v)
+	return s
+}
+
+func (p *printer) expr(v *Value) (string, int) {
+	if p.constant[v] {
+		return p.number(v.data)
+	}
+	if len(v.children) == 0 {
+		return v.label, precAtom
+	}
+
+	c := v.children
+	switch {
+	case v.fn != nil:
+		args := make([]string, len(c))
+		for i, child := range c {
+			args[i] = p.render(child, precSum)
+		}
+		return p.call(v.op, strings.Join(args, ", ")), precAtom
+	case v.op == "+", v.op == "+scalar":
+		a, b := c[0], c[1]
+		switch {
+		case p.isConst(b, 0):
+			return p.operand(a)
+		case p.isConst(a, 0):
+			return p.operand(b)
+		}
+		if x, ok := p.negated(b); ok {
+			return p.subtract(a, x)
+		}
+		if x, ok := p.negated(a); ok {
+			return p.subtract(b, x)
+		}
+		if p.constant[b] && b.data < 0 {
+			s, _ := p.number(-b.data)
+			return p.render(a, precSum) + " - " + s, precSum
+		}
+		return p.render(a, precSum) + " + " + p.render(b, precSum), precSum
+	case v.op == "*":
+		a, b := c[0], c[1]
+		switch {
+		case p.isConst(b, 1):
+			return p.operand(a)
+		case p.isConst(a, 1):
+			return p.operand(b)
+		}
+		if x, odd := p.unwrapNeg(v); x != v {
+			if !odd {
+				return p.operand(x)
+			}
+			return "-" + p.render(x, precProd), precProd
+		}
+		if x, ok := p.reciprocal(b); ok {
+			return p.divide(a, x)
+		}
+		if x, ok := p.reciprocal(a); ok {
+			return p.divide(b, x)
+		}
+		if p.constant[b] && !p.constant[a] {
+			a, b = b, a
+		}
+		op := " * "
+		if p.latex {
+			op = " \\cdot "
+		}
+		return p.render(a, precProd) + op + p.render(b, precProd+1), precProd
+	case strings.HasPrefix(v.op, "**"):
+		base, exp := c[0], c[1]
+		if p.isConst(exp, 1) {
+			return p.operand(base)
+		}
+		if x, ok := p.reciprocal(v); ok {
+			return p.divide(nil, x)
+		}
+		if p.latex {
+			return p.render(base, precAtom) + "^{" + p.render(exp, precSum) + "}", precPow
+		}
+		return p.render(base, precAtom) + "^" + p.render(exp, precAtom), precPow
+	case v.op == "relu":
+		return p.call("relu", p.render(c[0], precSum)), precAtom
+	case v.op == "exp":
+		if p.latex {
+			return "e^{" + p.render(c[0], precSum) + "}", precPow
+		}
+		return p.call("exp", p.render(c[0], precSum)), precAtom
+	case v.op == "log":
+		if p.latex {
+			return "\\ln\\left(" + p.render(c[0], precSum) + "\\right)", precAtom
+		}
+		return p.call("log", p.render(c[0], precSum)), precAtom
+	}
+
+	return p.call(v.op, ""), precAtom
+}
+
+// negated matches x * -1, the node Neg and Sub build, returning x.
+func (p *printer) negated(v *Value) (*Value, bool) {
+	if _, named := p.names[v]; named || v.op != "*" || p.constant[v] {
+		return nil, false
+	}
+	if p.isConst(v.children[1], -1) {
+		return v.children[0], true
+	}
+	if p.isConst(v.children[0], -1) {
+		return v.children[1], true
+	}
+
+	return nil, false
+}
+
+// subtract renders a - x, folding signs so a - -y prints as a + y.
+func (p *printer) subtract(a, x *Value) (string, int) {
+	y, odd := p.unwrapNeg(x)
+	if odd {
+		return p.render(a, precSum) + " + " + p.render(y, precSum), precSum
+	}
+
+	return p.render(a, precSum) + " - " + p.render(y, precProd), precSum
+}
+
+// unwrapNeg strips every negation around v, reporting whether there were an
+// odd number of them.
+func (p *printer) unwrapNeg(v *Value) (*Value, bool) {
+	odd := false
+	for {
+		x, ok := p.negated(v)
+		if !ok {
+			return v, odd
+		}
+		v, odd = x, !odd
+	}
+}
+
+// reciprocal matches x ** -1, the node Div builds, returning x.
+func (p *printer) reciprocal(v *Value) (*Value, bool) {
+	if _, named := p.names[v]; named || !strings.HasPrefix(v.op, "**") || p.constant[v] {
+		return nil, false
+	}
+	if p.isConst(v.children[1], -1) {
+		return v.children[0], true
+	}
+
+	return nil, false
+}
+
+func (p *printer) wraps(v *Value) (*Value, bool) {
+	if x, ok := p.negated(v); ok {
+		return x, true
+	}
+
+	return p.reciprocal(v)
+}
+
+// divide renders num / den, with a nil num meaning 1.
+func (p *printer) divide(num, den *Value) (string, int) {
+	if p.latex {
+		n := "1"
+		if num != nil {
+			n = p.render(num, precSum)
+		}
+		return "\\frac{" + n + "}{" + p.render(den, precSum) + "}", precAtom
+	}
+
+	n := "1"
+	if num != nil {
+		n = p.render(num, precProd)
+	}
+	return n + " / " + p.render(den, precProd+1), precProd
+}
+
+func (p *printer) call(name, args string) string {
+	if p.latex {
+		return "\\operatorname{" + name + "}\\left(" + args + "\\right)"
+	}
+
+	return name + "(" + args + ")"
+}
+
+func (p *printer) number(x float64) (string, int) {
+	s := strconv.FormatFloat(x, 'g', -1, 64)
+	if p.latex {
+		if mant, exp, ok := strings.Cut(s, "e"); ok {
+			exp = strings.TrimLeft(strings.TrimPrefix(exp, "+"), "0")
+			exp = strings.Replace(exp, "-0", "-", 1)
+			s = mant + " \\times 10^{" + exp + "}"
+			if mant == "1" {
+				s = "10^{" + exp + "}"
+			}
+		}
+	}
+	if x < 0 {
+		return s, precProd
+	}
+
+	return s, precAtom
+}
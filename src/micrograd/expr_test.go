@@ -0,0 +1,117 @@
+package micrograd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExprLecture(t *testing.T) {
+	// The first example from the micrograd lecture.
+	a := NewValue(2).SetLabel("a")
+	b := NewValue(-3).SetLabel("b")
+	c := NewValue(10).SetLabel("c")
+	e := a.Multiply(b).SetLabel("e")
+	d := e.Add(c).SetLabel("d")
+	f := NewValue(-2).SetLabel("f")
+	L := d.Multiply(f).SetLabel("L")
+
+	assert.Equal(t, "e = a * b\nd = e + c\nL = d * f", Expr(L))
+	assert.Equal(t, `\begin{aligned}
+e &= a \cdot b \\
+d &= e + c \\
+L &= d \cdot f \\
+\frac{\partial L}{\partial a} &= f \cdot b \\
+\frac{\partial L}{\partial b} &= f \cdot a \\
+\frac{\partial L}{\partial c} &= f \\
+\frac{\partial L}{\partial f} &= d
+\end{aligned}`, LaTeX(L))
+}
+
+func TestExprOperators(t *testing.T) {
+	x := NewValue(3).SetLabel("x")
+	y := NewValue(0.5).SetLabel("y")
+
+	tests := []struct {
+		v    *Value
+		want string
+	}{
+		{x.Add(y).Multiply(x), "(x + y) * x"},
+		{x.Sub(y).Sub(x.Sub(y)), "x - y - (x - y)"},
+		{x.Div(y.MulScalar(2)), "x / (2 * y)"},
+		{x.Neg().PowScalar(2), "(-x)^2"},
+		{y.Pow(x.AddScalar(1)), "y^(x + 1)"},
+		{x.AddScalar(-1.5).MulScalar(1).AddScalar(0), "x - 1.5"},
+		{x.Exp().Log().ReLU(), "relu(log(exp(x)))"},
+		{x.Sub(y.Neg()), "x + y"},
+		{x.Neg().Neg().Multiply(y), "x * y"},
+		{x.Sub(y.Multiply(x).Neg().Neg()), "x - y * x"},
+		{NewValue(2).MulScalar(3).Multiply(x), "6 * x"},
+		{Apply(hypot{}, x, y.PowScalar(-1)), "micrograd.hypot(x, 1 / y)"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Expr(tt.v))
+	}
+}
+
+func TestExprSharedSubexpressions(t *testing.T) {
+	x := NewValue(1).SetLabel("x")
+	y := NewValue(2).SetLabel("y")
+	s := x.Multiply(y).Exp()
+	u := s.Add(x)
+	out := u.Multiply(u).Add(s.Div(x))
+
+	assert.Equal(t, "t1 = exp(x * y)\nt2 = t1 + x\nt2 * t2 + t1 / x", Expr(out))
+
+	// A shared negation is printed in place and its operand named instead.
+	n := x.Add(y).Neg()
+	assert.Equal(t, "t1 = x + y\n-t1 * (-t1)", Expr(n.Multiply(n)))
+}
+
+// Function-style ops render under their op string.
+func TestExprMatchesOps(t *testing.T) {
+	x := NewValue(0.5).SetLabel("x")
+	for _, v := range []*Value{x.ReLU(), x.Exp(), x.Log()} {
+		assert.Equal(t, v.op+"(x)", Expr(v))
+	}
+}
+
+func TestLaTeXDerivatives(t *testing.T) {
+	x := NewValue(3).SetLabel("x")
+	y := NewValue(0.5).SetLabel("y")
+	s := x.Multiply(y).Exp()
+	out := s.Add(s.Div(x)).Sub(y.PowScalar(2)).MulScalar(2)
+
+	tex := LaTeX(out)
+	assert.Equal(t, `\begin{aligned}
+t_{1} &= e^{x \cdot y} \\
+f &= 2 \cdot \left(t_{1} + \frac{t_{1}}{x} - y^{2}\right) \\
+t_{2} &= \left(2 + \frac{2}{x}\right) \cdot t_{1} \\
+\frac{\partial f}{\partial x} &= -2 \cdot t_{1} \cdot x^{-2} + t_{2} \cdot y \\
+\frac{\partial f}{\partial y} &= -4 \cdot y + t_{2} \cdot x
+\end{aligned}`, tex)
+	assert.Equal(t, 1, strings.Count(tex, "e^{"), "exp(x*y) should be written once")
+}
+
+func TestLaTeXNumbers(t *testing.T) {
+	x := NewValue(1).SetLabel("x")
+	assert.Contains(t, LaTeX(x.MulScalar(2.5e-7)), `2.5 \times 10^{-7} \cdot x`)
+	assert.Contains(t, LaTeX(x.MulScalar(1e20)), `10^{20} \cdot x`)
+}
+
+func TestLabelsAreSaved(t *testing.T) {
+	x := NewValue(2).SetLabel("x")
+	y := x.Exp().SetLabel("y|z")
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDot(&buf, y))
+	assert.Contains(t, buf.String(), `{ x | data 2.0000`)
+	assert.Contains(t, buf.String(), `{ y\|z | data`)
+
+	g := roundTrip(t, y)
+	assert.Equal(t, "x", g.Nodes[0].Label())
+	assert.Equal(t, "y|z", g.Roots[0].Label())
+}
@@ -0,0 +1,114 @@
+package micrograd
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Function is an op defined outside this package. Forward computes the
+// output from the input data; Backward returns the gradient for each input
+// given the inputs, the output and the gradient flowing into the output.
+//
+// A Function that also has a Name() string method is labelled with it in
+// op strings, graph drawings and serialized graphs; otherwise its Go type
+// name is used. One with an Arity() int method has its input count checked
+// when a graph using it is loaded.
+type Function interface {
+	Forward(inputs []float64) float64
+	Backward(inputs []float64, out, gradOut float64) []float64
+}
+
+// GraphFunction is a Function that can also state its backward pass with
+// Value ops, which Grad with createGraph needs to differentiate through it
+// again. BackwardGraph gets the input and output nodes and the gradient
+// node flowing into the output, and returns a gradient node per input, nil
+// where none flows.
+type GraphFunction interface {
+	Function
+	BackwardGraph(inputs []*Value, out, gradOut *Value) []*Value
+}
+
+// Apply runs fn on values and records it in the graph like a built-in op.
+// Grad with createGraph panics on fn's nodes unless fn is a GraphFunction.
+func Apply(fn Function, values ...*Value) *Value {
+	inputs := make([]float64, len(values))
+	for i, v := range values {
+		inputs[i] = v.data
+	}
+
+	data := fn.Forward(inputs)
+	if !GradEnabled() {
+		return NewValue(data)
+	}
+	children := append([]*Value(nil), values...)
+	out := convertToValue(data, children, FunctionName(fn))
+	out.fn = fn
+
+	out.backward = func() {
+		for i, g := range out.fnGrads(out.grad) {
+			children[i].grad += g
+		}
+	}
+
+	return out
+}
+
+func (v *Value) fnGrads(gradOut float64) []float64 {
+	inputs := make([]float64, len(v.children))
+	for i, c := range v.children {
+		inputs[i] = c.data
+	}
+
+	grads := v.fn.Backward(inputs, v.data, gradOut)
+	if len(grads) != len(inputs) {
+		panic(fmt.Sprintf("micrograd: %s returned %d gradients for %d inputs", v.op, len(grads), len(inputs)))
+	}
+
+	return grads
+}
+
+// FunctionName is the op label Apply gives fn's nodes.
+func FunctionName(fn Function) string {
+	if named, ok := fn.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+
+	return fmt.Sprintf("%T", fn)
+}
+
+var functions = struct {
+	sync.RWMutex
+	byName map[string]Function
+}{byName: map[string]Function{}}
+
+// RegisterFunction makes fn known under FunctionName(fn), so graphs using it
+// can be loaded again. Registering the same name twice panics.
+func RegisterFunction(fn Function) {
+	name := FunctionName(fn)
+
+	functions.Lock()
+	defer functions.Unlock()
+
+	if _, dup := functions.byName[name]; dup {
+		panic("micrograd: RegisterFunction called twice for " + name)
+	}
+	functions.byName[name] = fn
+}
+
+// unregisterFunction forgets the Function registered under name, so tests
+// can register theirs again.
+func unregisterFunction(name string) {
+	functions.Lock()
+	defer functions.Unlock()
+
+	delete(functions.byName, name)
+}
+
+// LookupFunction returns the Function registered under name.
+func LookupFunction(name string) (Function, bool) {
+	functions.RLock()
+	defer functions.RUnlock()
+
+	fn, ok := functions.byName[name]
+	return fn, ok
+}
@@ -0,0 +1,170 @@
+package micrograd
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sigmoid struct{}
+
+func (sigmoid) Name() string { return "sigmoid" }
+
+func (sigmoid) Arity() int { return 1 }
+
+func (sigmoid) Forward(in []float64) float64 { return 1 / (1 + math.Exp(-in[0])) }
+
+func (sigmoid) Backward(_ []float64, out, gradOut float64) []float64 {
+	return []float64{out * (1 - out) * gradOut}
+}
+
+func (sigmoid) BackwardGraph(_ []*Value, out, gradOut *Value) []*Value {
+	return []*Value{out.Multiply(out.Neg().AddScalar(1)).Multiply(gradOut)}
+}
+
+// hypot has no Name method, so it is labelled by its type.
+type hypot struct{}
+
+func (hypot) Forward(in []float64) float64 { return math.Hypot(in[0], in[1]) }
+
+func (hypot) Backward(in []float64, out, gradOut float64) []float64 {
+	return []float64{in[0] / out * gradOut, in[1] / out * gradOut}
+}
+
+type broken struct{}
+
+func (broken) Forward([]float64) float64 { return 0 }
+
+func (broken) Backward([]float64, float64, float64) []float64 { return nil }
+
+func TestApplyBackward(t *testing.T) {
+	x := NewValue(0.5)
+	y := Apply(sigmoid{}, x.MulScalar(2)).Multiply(x)
+	y.Backward()
+
+	s := 1 / (1 + math.Exp(-1.0))
+	assert.InDelta(t, s*0.5, y.data, 1e-9)
+	// d/dx σ(2x)·x = 2σ'(2x)·x + σ(2x)
+	assert.InDelta(t, 2*s*(1-s)*0.5+s, x.grad, 1e-9)
+}
+
+func TestApplyMultipleInputs(t *testing.T) {
+	a := NewValue(3)
+	b := NewValue(4)
+	h := Apply(hypot{}, a, b)
+	h.Backward()
+
+	assert.Equal(t, 5.0, h.data)
+	assert.Equal(t, "micrograd.hypot", h.op)
+	assert.InDelta(t, 0.6, a.grad, 1e-9)
+	assert.InDelta(t, 0.8, b.grad, 1e-9)
+}
+
+func TestApplyWithGradAndNoGrad(t *testing.T) {
+	x := NewValue(0.3)
+	out := Apply(sigmoid{}, x).Multiply(x)
+
+	s := 1 / (1 + math.Exp(-0.3))
+	g := Grad(out, []*Value{x}, true)[0]
+	assert.InDelta(t, s*(1-s)*0.3+s, g.data, 1e-9)
+
+	NoGrad(func() {
+		leaf := Apply(sigmoid{}, x)
+		assert.InDelta(t, s, leaf.data, 1e-9)
+		assert.Nil(t, leaf.children)
+	})
+}
+
+// The Hessian of σ(xy)·x taken through sigmoid's BackwardGraph should match
+// central differences of its gradient.
+func TestApplyHessianMatchesFiniteDifferences(t *testing.T) {
+	f := func(x, y *Value) *Value { return Apply(sigmoid{}, x.Multiply(y)).Multiply(x) }
+	grad := func(x, y float64) []*Value {
+		xv, yv := NewValue(x), NewValue(y)
+		return Grad(f(xv, yv), []*Value{xv, yv}, false)
+	}
+
+	x, y := NewValue(0.7), NewValue(-1.3)
+	in := []*Value{x, y}
+	first := Grad(f(x, y), in, true)
+
+	const h = 1e-5
+	for i, g := range first {
+		row := Grad(g, in, false)
+		for j := range in {
+			var plus, minus []*Value
+			if j == 0 {
+				plus, minus = grad(0.7+h, -1.3), grad(0.7-h, -1.3)
+			} else {
+				plus, minus = grad(0.7, -1.3+h), grad(0.7, -1.3-h)
+			}
+			want := (plus[i].data - minus[i].data) / (2 * h)
+			assert.InDelta(t, want, row[j].data, 1e-7, "H[%d][%d]", i, j)
+		}
+	}
+}
+
+func TestGradGraphNeedsGraphFunction(t *testing.T) {
+	a, b := NewValue(3), NewValue(4)
+	out := Apply(hypot{}, a, b)
+
+	assert.PanicsWithValue(t, "micrograd: Grad with createGraph needs micrograd.hypot to implement BackwardGraph", func() {
+		Grad(out, []*Value{a, b}, true)
+	})
+	assert.InDelta(t, 0.6, Grad(out, []*Value{a, b}, false)[0].data, 1e-9, "First order gradients only need Backward")
+}
+
+func TestApplyAnomaly(t *testing.T) {
+	err := DetectAnomaly(func() {
+		Apply(hypot{}, NewValue(0), NewValue(0)).Backward()
+	})
+
+	var anomaly *AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "backward", anomaly.Phase)
+	assert.Equal(t, "micrograd.hypot", anomaly.Op)
+}
+
+func TestApplyWrongGradientCount(t *testing.T) {
+	out := Apply(broken{}, NewValue(1))
+	assert.PanicsWithValue(t, "micrograd: micrograd.broken returned 0 gradients for 1 inputs", out.Backward)
+}
+
+// register registers fn for the rest of the test.
+func register(t *testing.T, fn Function) {
+	t.Helper()
+	RegisterFunction(fn)
+	t.Cleanup(func() { unregisterFunction(FunctionName(fn)) })
+}
+
+func TestRegisterFunction(t *testing.T) {
+	register(t, sigmoid{})
+
+	fn, ok := LookupFunction("sigmoid")
+	require.True(t, ok)
+	assert.Equal(t, sigmoid{}, fn)
+
+	assert.Panics(t, func() { RegisterFunction(sigmoid{}) }, "Duplicate names should be rejected")
+
+	_, ok = LookupFunction("missing")
+	assert.False(t, ok)
+}
+
+func TestWriteDot(t *testing.T) {
+	x := NewValue(2)
+	y := Apply(sigmoid{}, x.Multiply(x))
+	y.Backward()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDot(&buf, y))
+	dot := buf.String()
+
+	assert.Contains(t, dot, "digraph micrograd {")
+	assert.Contains(t, dot, `label="sigmoid"`)
+	assert.Contains(t, dot, `label="*"`)
+	assert.Contains(t, dot, "data 2.0000")
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("v0 -> op1")), "x feeds the product twice")
+}
@@ -0,0 +1,218 @@
+package micrograd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+)
+
+var noGradDepth atomic.Int32
+
+// NoGrad runs fn with graph recording switched off: ops return leaf values
+// with no children or backward closures, so evaluation and sampling only
+// pay for the arithmetic. Calls nest.
+//
+// The switch is process wide, not per goroutine: while fn runs, graphs built
+// on every goroutine record nothing and get zero gradients. Use it only when
+// no other goroutine is building a graph it wants to differentiate. Code
+// that can run alongside training, such as evaluation or sampling, should
+// work on float64 data instead, as Grad without createGraph does.
+func NoGrad(fn func()) {
+	noGradDepth.Add(1)
+	defer noGradDepth.Add(-1)
+
+	fn()
+}
+
+// GradEnabled reports whether ops currently record the graph.
+func GradEnabled() bool {
+	return noGradDepth.Load() == 0
+}
+
+// Grad returns d out / d w for every w in wrt without touching any grad
+// field. Inputs out does not depend on get a zero gradient.
+//
+// With createGraph the gradients are themselves differentiable graphs over
+// the same inputs, so they can be differentiated again for second
+// derivatives, Hessian-vector products or gradient penalties. Without it
+// they are plain leaves. A Function in the graph must then be a
+// GraphFunction.
+func Grad(out *Value, wrt []*Value, createGraph bool) []*Value {
+	result := make([]*Value, len(wrt))
+	if !createGraph {
+		// Plain float64 accumulation: going through NoGrad would switch off
+		// recording on every goroutine, not just this one
+		grads := gradData(out)
+		for i, w := range wrt {
+			result[i] = NewValue(grads[w])
+		}
+		return result
+	}
+
+	grads := gradGraph(out)
+	for i, w := range wrt {
+		if g, ok := grads[w]; ok {
+			result[i] = g
+		} else {
+			result[i] = NewValue(0)
+		}
+	}
+
+	return result
+}
+
+// HVP returns the Hessian of out with respect to wrt multiplied by v,
+// computed as the gradient of grad(out)·v rather than by forming the
+// Hessian.
+func HVP(out *Value, wrt []*Value, v []float64) []float64 {
+	grads := Grad(out, wrt, true)
+
+	terms := make([]*Value, len(grads))
+	for i, g := range grads {
+		terms[i] = g.MulScalar(v[i])
+	}
+
+	hv := make([]float64, len(wrt))
+	for i, g := range Grad(Sum(terms), wrt, false) {
+		hv[i] = g.data
+	}
+
+	return hv
+}
+
+// gradData runs reverse mode with float64 gradients held in a map, leaving
+// every grad field alone.
+func gradData(out *Value) map[*Value]float64 {
+	grads := map[*Value]float64{out: 1}
+	topo := out.topo()
+	for i := len(topo) - 1; i >= 0; i-- {
+		node := topo[i]
+		g, ok := grads[node]
+		if !ok || len(node.children) == 0 {
+			continue
+		}
+
+		for j, cg := range node.localGrads(g) {
+			grads[node.children[j]] += cg
+		}
+	}
+
+	return grads
+}
+
+// localGrads is vjp for float64 gradients: given the gradient g of the
+// output it returns the gradient for each child, matching v.backward.
+func (v *Value) localGrads(g float64) []float64 {
+	switch {
+	case v.fn != nil:
+		return v.fnGrads(g)
+	case v.op == "+":
+		return []float64{g, g}
+	case v.op == "+scalar":
+		return []float64{g, 0}
+	case v.op == "*":
+		a, b := v.children[0], v.children[1]
+		return []float64{g * b.data, g * a.data}
+	case strings.HasPrefix(v.op, "**"):
+		base, exp := v.children[0], v.children[1]
+		grads := make([]float64, 2)
+		// Mirrors the guards in Pow's backward
+		if base.data != 0 {
+			grads[0] = g * exp.data * math.Pow(base.data, exp.data-1)
+		}
+		if base.data > 0 {
+			grads[1] = g * v.data * math.Log(base.data)
+		}
+		return grads
+	case v.op == "relu":
+		if v.children[0].data > 0 {
+			return []float64{g}
+		}
+		return []float64{0}
+	case v.op == "exp":
+		return []float64{g * v.data}
+	case v.op == "log":
+		return []float64{g / v.children[0].data}
+	}
+
+	panic(fmt.Sprintf("micrograd: no gradient for op %q", v.op))
+}
+
+// gradGraph runs reverse mode with Value gradients, summing the
+// contributions each node receives from its parents.
+func gradGraph(out *Value) map[*Value]*Value {
+	grads := map[*Value]*Value{out: NewValue(1)}
+	topo := out.topo()
+	for i := len(topo) - 1; i >= 0; i-- {
+		node := topo[i]
+		g, ok := grads[node]
+		if !ok {
+			continue
+		}
+
+		for j, cg := range node.vjp(g) {
+			if cg == nil {
+				continue
+			}
+			child := node.children[j]
+			if prev, ok := grads[child]; ok {
+				grads[child] = prev.Add(cg)
+			} else {
+				grads[child] = cg
+			}
+		}
+	}
+
+	return grads
+}
+
+// vjp is the symbolic counterpart of v.backward: given the gradient g of
+// the output it returns the gradient for each child, built from Value ops
+// so it can be differentiated again. A nil entry means no gradient flows to
+// that child. Every op in engine.go needs a case here and in localGrads.
+func (v *Value) vjp(g *Value) []*Value {
+	switch {
+	case len(v.children) == 0:
+		return nil
+	case v.fn != nil:
+		fn, ok := v.fn.(GraphFunction)
+		if !ok {
+			panic(fmt.Sprintf("micrograd: Grad with createGraph needs %s to implement BackwardGraph", v.op))
+		}
+		grads := fn.BackwardGraph(v.children, v, g)
+		if len(grads) != len(v.children) {
+			panic(fmt.Sprintf("micrograd: %s returned %d gradient graphs for %d inputs", v.op, len(grads), len(v.children)))
+		}
+		return grads
+	case v.op == "+":
+		return []*Value{g, g}
+	case v.op == "+scalar":
+		return []*Value{g, nil}
+	case v.op == "*":
+		a, b := v.children[0], v.children[1]
+		return []*Value{g.Multiply(b), g.Multiply(a)}
+	case strings.HasPrefix(v.op, "**"):
+		base, exp := v.children[0], v.children[1]
+		grads := make([]*Value, 2)
+		// Mirrors the guards in Pow's backward
+		if base.data != 0 {
+			grads[0] = g.Multiply(exp).Multiply(base.Pow(exp.AddScalar(-1)))
+		}
+		if base.data > 0 {
+			grads[1] = g.Multiply(v).Multiply(base.Log())
+		}
+		return grads
+	case v.op == "relu":
+		if v.children[0].data > 0 {
+			return []*Value{g}
+		}
+		return []*Value{nil}
+	case v.op == "exp":
+		return []*Value{g.Multiply(v)}
+	case v.op == "log":
+		return []*Value{g.Div(v.children[0])}
+	}
+
+	panic(fmt.Sprintf("micrograd: no symbolic gradient for op %q", v.op))
+}
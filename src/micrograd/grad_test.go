@@ -0,0 +1,198 @@
+package micrograd
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoGradBuildsNoGraph(t *testing.T) {
+	a := NewValue(2.0)
+	b := NewValue(-3.0)
+
+	var out *Value
+	NoGrad(func() {
+		out = a.Multiply(b).Add(a.PowScalar(2)).ReLU().AddScalar(1).Exp().Log()
+	})
+
+	assert.InDelta(t, 1.0, out.data, 1e-9, "NoGrad should not change the forward computation")
+	assert.Nil(t, out.children, "NoGrad results should be leaves")
+	assert.Empty(t, out.op)
+
+	out.Backward()
+	assert.Zero(t, a.grad, "Gradients should not reach inputs through a NoGrad result")
+	assert.True(t, GradEnabled(), "Recording should resume after NoGrad returns")
+}
+
+func TestNoGradNests(t *testing.T) {
+	NoGrad(func() {
+		NoGrad(func() {
+			assert.False(t, GradEnabled())
+		})
+		assert.False(t, GradEnabled(), "Leaving an inner NoGrad should not re-enable recording")
+	})
+	assert.True(t, GradEnabled())
+}
+
+func TestNoGradRestoresOnPanic(t *testing.T) {
+	assert.Panics(t, func() {
+		NoGrad(func() { panic("boom") })
+	})
+	assert.True(t, GradEnabled())
+}
+
+func TestDetach(t *testing.T) {
+	a := NewValue(3.0)
+	b := a.Multiply(a)
+	c := b.Detach().Multiply(a) // d/da = b.data only, the b path is cut
+
+	c.Backward()
+
+	assert.InDelta(t, 27.0, c.data, 1e-9)
+	assert.InDelta(t, 9.0, a.grad, 1e-9, "Detach should block gradients into the detached graph")
+	assert.Zero(t, b.grad)
+}
+
+func TestNoGradAllocatesLess(t *testing.T) {
+	mlp := NewMLP(4, []int{16, 16, 1}, rand.New(rand.NewSource(1)))
+	x := []*Value{NewValue(1), NewValue(-1), NewValue(0.5), NewValue(2)}
+
+	withGraph := testing.AllocsPerRun(10, func() { mlp.Call(x) })
+	withoutGraph := testing.AllocsPerRun(10, func() {
+		NoGrad(func() { mlp.Call(x) })
+	})
+
+	assert.Less(t, withoutGraph, withGraph/2, "NoGrad should skip children and backward closures")
+}
+
+func TestGradMatchesBackward(t *testing.T) {
+	build := func() (*Value, *Value, *Value) {
+		x := NewValue(-4.0)
+		y := NewValue(1.5)
+		z := x.MulScalar(2).AddScalar(2).Add(x.Multiply(y))
+		q := z.ReLU().Add(z.Multiply(x)).Add(y.Exp())
+		return q.Add(y.PowScalar(3)).Add(y.Log()).Div(y), x, y
+	}
+
+	out, x, y := build()
+	grads := Grad(out, []*Value{x, y}, false)
+	assert.Zero(t, x.grad, "Grad should not write grad fields")
+
+	out.Backward()
+	assert.InDelta(t, x.grad, grads[0].data, 1e-9)
+	assert.InDelta(t, y.grad, grads[1].data, 1e-9)
+	assert.Nil(t, grads[0].children, "Without createGraph gradients are leaves")
+}
+
+func TestGradUnusedInputIsZero(t *testing.T) {
+	x := NewValue(2.0)
+	unused := NewValue(5.0)
+
+	grads := Grad(x.Multiply(x), []*Value{x, unused}, false)
+	assert.InDelta(t, 4.0, grads[0].data, 1e-9)
+	assert.Zero(t, grads[1].data)
+}
+
+// pause is the identity, whose Backward waits until it is released so a
+// test can act while a gradient computation is in progress.
+type pause struct {
+	inside, resume chan struct{}
+}
+
+func (p pause) Forward(inputs []float64) float64 { return inputs[0] }
+
+func (p pause) Backward(inputs []float64, out, gradOut float64) []float64 {
+	close(p.inside)
+	<-p.resume
+	return []float64{gradOut}
+}
+
+func TestGradLeavesOtherGoroutinesRecording(t *testing.T) {
+	fn := pause{inside: make(chan struct{}), resume: make(chan struct{})}
+	x := NewValue(3)
+	y := Apply(fn, x)
+
+	done := make(chan []*Value)
+	go func() { done <- Grad(y, []*Value{x}, false) }()
+	<-fn.inside
+
+	// A graph built while Grad is running elsewhere must still record
+	a := NewValue(2)
+	out := a.Multiply(a).Add(a)
+	close(fn.resume)
+	out.Backward()
+	assert.Equal(t, 5.0, a.Grad())
+	assert.Equal(t, 1.0, (<-done)[0].Data())
+}
+
+func TestSecondDerivatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      func(x *Value) *Value
+		x      float64
+		d1, d2 float64
+	}{
+		{"cube", func(x *Value) *Value { return x.PowScalar(3) }, 1.5, 3 * 1.5 * 1.5, 6 * 1.5},
+		{"exp", func(x *Value) *Value { return x.MulScalar(2).Exp() }, 0.3, 2 * math.Exp(0.6), 4 * math.Exp(0.6)},
+		{"log", func(x *Value) *Value { return x.Log() }, 2.0, 0.5, -0.25},
+		{"reciprocal", func(x *Value) *Value { return NewValue(1).Div(x) }, 2.0, -0.25, 0.25},
+		{"relu", func(x *Value) *Value { return x.Multiply(x).ReLU() }, -3.0, -6, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewValue(tt.x)
+			d1 := Grad(tt.f(x), []*Value{x}, true)[0]
+			d2 := Grad(d1, []*Value{x}, false)[0]
+
+			assert.InDelta(t, tt.d1, d1.data, 1e-9, "First derivative mismatch")
+			assert.InDelta(t, tt.d2, d2.data, 1e-9, "Second derivative mismatch")
+		})
+	}
+}
+
+// f(x, y) = x²y + y³ has Hessian [[2y, 2x], [2x, 6y]].
+func TestHVP(t *testing.T) {
+	x := NewValue(1.5)
+	y := NewValue(-2.0)
+	f := x.Multiply(x).Multiply(y).Add(y.PowScalar(3))
+
+	hv := HVP(f, []*Value{x, y}, []float64{1, 3})
+
+	h := [2][2]float64{{2 * -2.0, 2 * 1.5}, {2 * 1.5, 6 * -2.0}}
+	assert.InDelta(t, h[0][0]*1+h[0][1]*3, hv[0], 1e-9)
+	assert.InDelta(t, h[1][0]*1+h[1][1]*3, hv[1], 1e-9)
+}
+
+// A gradient penalty differentiates through the gradient of the output with
+// respect to the input. For f = w·x², df/dx = 2wx and the penalty
+// (df/dx)² = 4w²x² has d/dw = 8wx².
+func TestGradientPenalty(t *testing.T) {
+	w := NewValue(0.5)
+	x := NewValue(3.0)
+	f := w.Multiply(x.Multiply(x))
+
+	dx := Grad(f, []*Value{x}, true)[0]
+	penalty := dx.Multiply(dx)
+	dw := Grad(penalty, []*Value{w}, false)[0]
+
+	assert.InDelta(t, 2*0.5*3, dx.data, 1e-9)
+	assert.InDelta(t, 8*0.5*9, dw.data, 1e-9)
+}
+
+// Newton's method on f(x) = x⁴ - 3x² + x, stepping by the first derivative
+// over the second, should land on a stationary point.
+func TestNewtonStep(t *testing.T) {
+	x := 2.0
+	for i := 0; i < 20; i++ {
+		xv := NewValue(x)
+		f := xv.PowScalar(4).Sub(xv.PowScalar(2).MulScalar(3)).Add(xv)
+		d1 := Grad(f, []*Value{xv}, true)[0]
+		d2 := Grad(d1, []*Value{xv}, false)[0]
+		x -= d1.data / d2.data
+	}
+
+	assert.InDelta(t, 0.0, 4*math.Pow(x, 3)-6*x+1, 1e-9, "Newton should converge to f'(x) = 0")
+}
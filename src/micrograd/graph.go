@@ -0,0 +1,268 @@
+package micrograd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GraphVersion is the version of the JSON graph format WriteGraph produces.
+const GraphVersion = 1
+
+// Graph is a loaded graph: its roots and every node they depend on, inputs
+// before the nodes that use them.
+type Graph struct {
+	Roots []*Value
+	Nodes []*Value
+}
+
+// NewGraph collects the graph behind roots.
+func NewGraph(roots ...*Value) *Graph {
+	return &Graph{Roots: roots, Nodes: topoAll(roots)}
+}
+
+// Forward recomputes every computed node from its inputs, so changes to
+// leaf data propagate to the roots.
+func (g *Graph) Forward() {
+	for _, v := range g.Nodes {
+		if len(v.children) > 0 {
+			v.data = v.eval()
+		}
+	}
+}
+
+// ZeroGrad clears every gradient in the graph.
+func (g *Graph) ZeroGrad() {
+	for _, v := range g.Nodes {
+		v.grad = 0
+	}
+}
+
+// eval computes v's data from its children. Every op in engine.go needs a
+// case here.
+func (v *Value) eval() float64 {
+	c := v.children
+	switch {
+	case v.fn != nil:
+		inputs := make([]float64, len(c))
+		for i, child := range c {
+			inputs[i] = child.data
+		}
+		return v.fn.Forward(inputs)
+	case v.op == "+", v.op == "+scalar":
+		return c[0].data + c[1].data
+	case v.op == "*":
+		return c[0].data * c[1].data
+	case strings.HasPrefix(v.op, "**"):
+		return math.Pow(c[0].data, c[1].data)
+	case v.op == "relu":
+		return math.Max(0, c[0].data)
+	case v.op == "exp":
+		return math.Exp(c[0].data)
+	case v.op == "log":
+		return math.Log(c[0].data)
+	}
+
+	panic(fmt.Sprintf("micrograd: cannot evaluate op %q", v.op))
+}
+
+// rebuild applies op to inputs, creating a node with the same backward as
+// the engine would.
+func rebuild(op, function string, inputs []*Value) (v *Value, err error) {
+	arity := func(n int) error {
+		if len(inputs) != n {
+			return fmt.Errorf("op %q takes %d inputs, got %d", op, n, len(inputs))
+		}
+		return nil
+	}
+
+	if function != "" {
+		fn, ok := LookupFunction(function)
+		if !ok {
+			return nil, fmt.Errorf("function %q is not registered", function)
+		}
+		if f, ok := fn.(interface{ Arity() int }); ok && f.Arity() != len(inputs) {
+			return nil, fmt.Errorf("function %q takes %d inputs, got %d", function, f.Arity(), len(inputs))
+		}
+		// Without an Arity method the input count is only checked by
+		// Forward, which may index past the end
+		defer func() {
+			if r := recover(); r != nil {
+				v, err = nil, fmt.Errorf("function %q on %d inputs: %v", function, len(inputs), r)
+			}
+		}()
+		return Apply(fn, inputs...), nil
+	}
+
+	switch {
+	case op == "+":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return inputs[0].Add(inputs[1]), nil
+	case op == "+scalar":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return inputs[0].addScalar(inputs[1]), nil
+	case op == "*":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return inputs[0].Multiply(inputs[1]), nil
+	case strings.HasPrefix(op, "**"):
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		return inputs[0].Pow(inputs[1]), nil
+	case op == "relu":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return inputs[0].ReLU(), nil
+	case op == "exp":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return inputs[0].Exp(), nil
+	case op == "log":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		return inputs[0].Log(), nil
+	}
+
+	return nil, fmt.Errorf("unknown op %q", op)
+}
+
+type graphJSON struct {
+	Version int        `json:"version"`
+	Roots   []int      `json:"roots"`
+	Nodes   []nodeJSON `json:"nodes"`
+}
+
+// nodeJSON is one node. Inputs refer to earlier nodes by index. Nodes made
+// by Apply name their registered Function instead of an op.
+type nodeJSON struct {
+	Op       string    `json:"op,omitempty"`
+	Function string    `json:"function,omitempty"`
+	Label    string    `json:"label,omitempty"`
+	Data     jsonFloat `json:"data"`
+	Grad     jsonFloat `json:"grad"`
+	Inputs   []int     `json:"inputs,omitempty"`
+}
+
+// jsonFloat writes NaN and ±Inf as strings, since graphs attached to bug
+// reports are often the ones that produced them.
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	switch x := float64(f); {
+	case math.IsNaN(x):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(x, 1):
+		return []byte(`"+Inf"`), nil
+	case math.IsInf(x, -1):
+		return []byte(`"-Inf"`), nil
+	default:
+		return strconv.AppendFloat(nil, x, 'g', -1, 64), nil
+	}
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("bad number %q", s)
+		}
+		*f = jsonFloat(x)
+		return nil
+	}
+
+	var x float64
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	*f = jsonFloat(x)
+	return nil
+}
+
+// WriteGraph writes the graph behind roots as JSON: every node with its op,
+// data, grad and input edges, in an order that ReadGraph can rebuild.
+func WriteGraph(w io.Writer, roots ...*Value) error {
+	g := NewGraph(roots...)
+	ids := make(map[*Value]int, len(g.Nodes))
+	out := graphJSON{Version: GraphVersion, Nodes: make([]nodeJSON, len(g.Nodes))}
+
+	for i, v := range g.Nodes {
+		ids[v] = i
+		node := nodeJSON{Op: v.opString(), Label: v.label, Data: jsonFloat(v.data), Grad: jsonFloat(v.grad)}
+		if v.fn != nil {
+			node.Op, node.Function = "", v.op
+		}
+		for _, c := range v.children {
+			node.Inputs = append(node.Inputs, ids[c])
+		}
+		out.Nodes[i] = node
+	}
+	for _, r := range roots {
+		out.Roots = append(out.Roots, ids[r])
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ReadGraph loads a graph written by WriteGraph. Leaves get their saved
+// data, computed nodes are rebuilt with the engine's ops, so the result can
+// be re-evaluated with Forward and differentiated with Backward or Grad.
+// Saved gradients are restored on every node.
+func ReadGraph(r io.Reader) (*Graph, error) {
+	var in graphJSON
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("read graph: %w", err)
+	}
+	if in.Version != GraphVersion {
+		return nil, fmt.Errorf("read graph: unsupported version %d", in.Version)
+	}
+
+	g := &Graph{Nodes: make([]*Value, len(in.Nodes))}
+	for i, node := range in.Nodes {
+		if len(node.Inputs) == 0 && node.Function == "" {
+			g.Nodes[i] = convertToValue(float64(node.Data), nil, node.Op)
+			g.Nodes[i].grad = float64(node.Grad)
+			g.Nodes[i].label = node.Label
+			continue
+		}
+
+		inputs := make([]*Value, len(node.Inputs))
+		for j, id := range node.Inputs {
+			if id < 0 || id >= i {
+				return nil, fmt.Errorf("read graph: node %d uses node %d before it is defined", i, id)
+			}
+			inputs[j] = g.Nodes[id]
+		}
+
+		v, err := rebuild(node.Op, node.Function, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("read graph: node %d: %w", i, err)
+		}
+		v.grad = float64(node.Grad)
+		v.label = node.Label
+		g.Nodes[i] = v
+	}
+
+	for _, id := range in.Roots {
+		if id < 0 || id >= len(g.Nodes) {
+			return nil, fmt.Errorf("read graph: root %d out of range", id)
+		}
+		g.Roots = append(g.Roots, g.Nodes[id])
+	}
+
+	return g, nil
+}
@@ -0,0 +1,179 @@
+package micrograd
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTrip writes roots and reads them back.
+func roundTrip(t *testing.T, roots ...*Value) *Graph {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, WriteGraph(&buf, roots...))
+	g, err := ReadGraph(&buf)
+	require.NoError(t, err)
+	return g
+}
+
+// lecture is the expression from the micrograd lecture, with every op the
+// engine has.
+func lecture() (inputs []*Value, out *Value) {
+	a, b := NewValue(-4), NewValue(2)
+	c := a.Add(b)
+	d := a.Multiply(b).Add(b.PowScalar(3))
+	c = c.Add(c.AddScalar(1))
+	d = d.Add(d.MulScalar(2).Add(b.Add(a).ReLU()))
+	e := c.Sub(d).Exp().AddScalar(1).Log()
+	f := e.PowScalar(2).Div(d.Neg().AddScalar(1))
+	return []*Value{a, b}, f
+}
+
+func TestGraphRoundTrip(t *testing.T) {
+	inputs, out := lecture()
+	out.Backward()
+
+	g := roundTrip(t, out)
+	want := NewGraph(out)
+	require.Len(t, g.Nodes, len(want.Nodes))
+	require.Len(t, g.Roots, 1)
+	for i, v := range g.Nodes {
+		assert.Equal(t, want.Nodes[i].op, v.op, "node %d", i)
+		assert.Equal(t, want.Nodes[i].data, v.data, "node %d", i)
+		assert.Equal(t, want.Nodes[i].grad, v.grad, "node %d", i)
+		assert.Len(t, v.children, len(want.Nodes[i].children), "node %d", i)
+	}
+	assert.Equal(t, out.data, g.Roots[0].data)
+	assert.Equal(t, inputs[0].grad, g.Nodes[0].grad)
+}
+
+func TestGraphIsStable(t *testing.T) {
+	_, out := lecture()
+	var first, second bytes.Buffer
+	require.NoError(t, WriteGraph(&first, out))
+	require.NoError(t, WriteGraph(&second, roundTrip(t, out).Roots...))
+	assert.Equal(t, first.String(), second.String())
+}
+
+func TestGraphForward(t *testing.T) {
+	_, out := lecture()
+	g := roundTrip(t, out)
+
+	// The two leaves are the first nodes, in the order they were created.
+	g.Nodes[0].SetData(3)
+	g.Nodes[1].SetData(-1.5)
+	g.Forward()
+
+	inputs, want := lecture()
+	inputs[0].SetData(3)
+	inputs[1].SetData(-1.5)
+	NewGraph(want).Forward()
+	assert.InDelta(t, want.data, g.Roots[0].data, 1e-12)
+}
+
+func TestGraphBackward(t *testing.T) {
+	inputs, out := lecture()
+	out.Backward()
+
+	g := roundTrip(t, out)
+	g.ZeroGrad()
+	g.Roots[0].Backward()
+
+	assert.InDelta(t, inputs[0].grad, g.Nodes[0].grad, 1e-12)
+	assert.InDelta(t, inputs[1].grad, g.Nodes[1].grad, 1e-12)
+
+	grads := Grad(g.Roots[0], g.Nodes[:2], false)
+	assert.InDelta(t, inputs[0].grad, grads[0].data, 1e-12)
+	assert.InDelta(t, inputs[1].grad, grads[1].data, 1e-12)
+}
+
+func TestGraphNonFinite(t *testing.T) {
+	x := NewValue(math.Inf(1))
+	y := x.Multiply(NewValue(0))
+	y.SetGrad(math.Inf(-1))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteGraph(&buf, y))
+	assert.Contains(t, buf.String(), `"+Inf"`)
+	assert.Contains(t, buf.String(), `"NaN"`)
+
+	g, err := ReadGraph(&buf)
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(g.Roots[0].data))
+	assert.True(t, math.IsInf(g.Roots[0].grad, -1))
+	assert.True(t, math.IsInf(g.Nodes[0].data, 1))
+}
+
+func TestGraphFunction(t *testing.T) {
+	fn := sigmoid{}
+	register(t, fn)
+
+	x := NewValue(0.3)
+	y := Apply(fn, x.MulScalar(2))
+	y.Backward()
+
+	g := roundTrip(t, y)
+	root := g.Roots[0]
+	assert.Equal(t, fn, root.fn)
+	assert.InDelta(t, y.data, root.data, 1e-12)
+
+	g.ZeroGrad()
+	root.Backward()
+	assert.InDelta(t, x.grad, g.Nodes[0].grad, 1e-12)
+}
+
+// A power node's op names its exponent, which Forward can change.
+func TestGraphWritesCurrentExponent(t *testing.T) {
+	x, n := NewValue(2), NewValue(2)
+	g := NewGraph(x.Pow(n))
+	n.SetData(3)
+	g.Forward()
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteGraph(&buf, g.Roots...))
+	assert.Contains(t, buf.String(), `"op": "**3.000000"`)
+	assert.Equal(t, 8.0, roundTrip(t, g.Roots...).Roots[0].data)
+}
+
+// sigmoid declares its arity. hypot has no Arity method, so a wrong input
+// count is only caught when Forward runs.
+func TestReadGraphFunctionArity(t *testing.T) {
+	register(t, sigmoid{})
+	register(t, hypot{})
+	node := `{"version": 1, "roots": [2], "nodes": [{"data": 1, "grad": 0}, {"data": 2, "grad": 0}, {"function": %q, "data": 1, "grad": 0, "inputs": [%s]}]}`
+
+	_, err := ReadGraph(strings.NewReader(fmt.Sprintf(node, "sigmoid", "0, 1")))
+	assert.EqualError(t, err, `read graph: node 2: function "sigmoid" takes 1 inputs, got 2`)
+
+	_, err = ReadGraph(strings.NewReader(fmt.Sprintf(node, "micrograd.hypot", "0")))
+	assert.ErrorContains(t, err, `read graph: node 2: function "micrograd.hypot" on 1 inputs: runtime error: index out of range`)
+
+	g, err := ReadGraph(strings.NewReader(fmt.Sprintf(node, "micrograd.hypot", "0, 1")))
+	require.NoError(t, err)
+	assert.InDelta(t, math.Sqrt(5), g.Roots[0].data, 1e-12)
+}
+
+func TestReadGraphErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad json":       `{`,
+		"version":        `{"version": 2, "roots": [], "nodes": []}`,
+		"unknown op":     `{"version": 1, "roots": [1], "nodes": [{"data": 1, "grad": 0}, {"op": "tanh", "data": 1, "grad": 0, "inputs": [0]}]}`,
+		"arity":          `{"version": 1, "roots": [1], "nodes": [{"data": 1, "grad": 0}, {"op": "*", "data": 1, "grad": 0, "inputs": [0]}]}`,
+		"forward edge":   `{"version": 1, "roots": [0], "nodes": [{"op": "ReLU", "data": 1, "grad": 0, "inputs": [1]}, {"data": 1, "grad": 0}]}`,
+		"unknown fn":     `{"version": 1, "roots": [1], "nodes": [{"data": 1, "grad": 0}, {"function": "missing", "data": 1, "grad": 0, "inputs": [0]}]}`,
+		"root range":     `{"version": 1, "roots": [5], "nodes": [{"data": 1, "grad": 0}]}`,
+		"bad non-finite": `{"version": 1, "roots": [0], "nodes": [{"data": "huge", "grad": 0}]}`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ReadGraph(strings.NewReader(input))
+			assert.ErrorContains(t, err, "read graph")
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package micrograd
+
+func Sum(values []*Value) *Value {
+	if len(values) == 0 {
+		return NewValue(0)
+	}
+
+	out := values[0]
+	for _, v := range values[1:] {
+		out = out.Add(v)
+	}
+
+	return out
+}
+
+func Mean(values []*Value) *Value {
+	return Sum(values).DivScalar(float64(len(values)))
+}
+
+// CrossEntropy returns -log(softmax(logits)[target]). The largest logit is
+// subtracted first so Exp cannot overflow; it is a constant, so gradients are
+// unchanged.
+func CrossEntropy(logits []*Value, target int) *Value {
+	maxLogit := logits[0].data
+	for _, l := range logits[1:] {
+		if l.data > maxLogit {
+			maxLogit = l.data
+		}
+	}
+
+	exps := make([]*Value, len(logits))
+	for i, l := range logits {
+		exps[i] = l.AddScalar(-maxLogit).Exp()
+	}
+
+	return Sum(exps).Log().Sub(logits[target].AddScalar(-maxLogit))
+}
+
+// Hinge is the SVM loss mean(max(0, 1 - y*score)) for labels y of -1 or +1.
+func Hinge(scores []*Value, labels []float64) *Value {
+	losses := make([]*Value, len(scores))
+	for i, s := range scores {
+		losses[i] = s.MulScalar(-labels[i]).AddScalar(1).ReLU()
+	}
+
+	return Mean(losses)
+}
+
+// MultiHinge is the multiclass SVM loss sum over j != target of
+// max(0, 1 + scores[j] - scores[target]).
+func MultiHinge(scores []*Value, target int) *Value {
+	losses := make([]*Value, 0, len(scores)-1)
+	for j, s := range scores {
+		if j != target {
+			losses = append(losses, s.Sub(scores[target]).AddScalar(1).ReLU())
+		}
+	}
+
+	return Sum(losses)
+}
+
+// L2 is the weight decay penalty alpha * sum(p²).
+func L2(params []*Value, alpha float64) *Value {
+	squares := make([]*Value, len(params))
+	for i, p := range params {
+		squares[i] = p.Multiply(p)
+	}
+
+	return Sum(squares).MulScalar(alpha)
+}
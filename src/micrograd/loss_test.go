@@ -0,0 +1,88 @@
+package micrograd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSumAndMean(t *testing.T) {
+	a := NewValue(1.0)
+	b := NewValue(2.0)
+	c := NewValue(3.0)
+
+	m := Mean([]*Value{a, b, c})
+	m.Backward()
+
+	assert.InDelta(t, 2.0, m.data, 1e-9, "Mean forward computation mismatch")
+	for _, v := range []*Value{a, b, c} {
+		assert.InDelta(t, 1.0/3.0, v.grad, 1e-9, "Mean backward gradient mismatch")
+	}
+}
+
+func TestCrossEntropy(t *testing.T) {
+	logits := []*Value{NewValue(1.0), NewValue(2.0), NewValue(3.0)}
+
+	loss := CrossEntropy(logits, 2)
+	loss.Backward()
+
+	// Softmax probabilities computed by hand
+	z := math.Exp(1) + math.Exp(2) + math.Exp(3)
+	probs := []float64{math.Exp(1) / z, math.Exp(2) / z, math.Exp(3) / z}
+
+	assert.InDelta(t, -math.Log(probs[2]), loss.data, 1e-9, "Cross entropy forward mismatch")
+
+	// d(loss)/d(logit_i) = p_i - 1[i == target]
+	assert.InDelta(t, probs[0], logits[0].grad, 1e-9, "Cross entropy gradient mismatch for logit 0")
+	assert.InDelta(t, probs[1], logits[1].grad, 1e-9, "Cross entropy gradient mismatch for logit 1")
+	assert.InDelta(t, probs[2]-1, logits[2].grad, 1e-9, "Cross entropy gradient mismatch for logit 2")
+}
+
+func TestCrossEntropyLargeLogits(t *testing.T) {
+	logits := []*Value{NewValue(1000.0), NewValue(0.0)}
+
+	loss := CrossEntropy(logits, 0)
+
+	assert.False(t, math.IsNaN(loss.data) || math.IsInf(loss.data, 0), "Cross entropy should be finite for large logits")
+	assert.InDelta(t, 0.0, loss.data, 1e-9, "Cross entropy forward mismatch for large logits")
+}
+
+func TestHinge(t *testing.T) {
+	scores := []*Value{NewValue(2.0), NewValue(0.5), NewValue(0.25)}
+	labels := []float64{1, 1, -1}
+
+	loss := Hinge(scores, labels)
+	loss.Backward()
+
+	// Margins: 1-2 = -1 (satisfied), 1-0.5 = 0.5, 1+0.25 = 1.25
+	assert.InDelta(t, (0.5+1.25)/3, loss.data, 1e-9, "Hinge forward computation mismatch")
+	assert.InDelta(t, 0.0, scores[0].grad, 1e-9, "A satisfied margin should get no gradient")
+	assert.InDelta(t, -1.0/3, scores[1].grad, 1e-9, "Hinge backward gradient mismatch")
+	assert.InDelta(t, 1.0/3, scores[2].grad, 1e-9, "Hinge backward gradient mismatch")
+}
+
+func TestMultiHinge(t *testing.T) {
+	scores := []*Value{NewValue(1.0), NewValue(3.0), NewValue(2.5)}
+
+	loss := MultiHinge(scores, 1)
+	loss.Backward()
+
+	// max(0, 1+1-3) + max(0, 1+2.5-3) = 0 + 0.5
+	assert.InDelta(t, 0.5, loss.data, 1e-9, "MultiHinge forward computation mismatch")
+	assert.InDelta(t, 0.0, scores[0].grad, 1e-9)
+	assert.InDelta(t, -1.0, scores[1].grad, 1e-9)
+	assert.InDelta(t, 1.0, scores[2].grad, 1e-9)
+}
+
+func TestL2(t *testing.T) {
+	a := NewValue(2.0)
+	b := NewValue(-3.0)
+
+	loss := L2([]*Value{a, b}, 0.1)
+	loss.Backward()
+
+	assert.InDelta(t, 1.3, loss.data, 1e-9, "L2 forward computation mismatch")
+	assert.InDelta(t, 0.4, a.grad, 1e-9, "L2 backward gradient mismatch for a")
+	assert.InDelta(t, -0.6, b.grad, 1e-9, "L2 backward gradient mismatch for b")
+}
@@ -0,0 +1,144 @@
+package micrograd
+
+import (
+	"math"
+	"math/rand"
+)
+
+type Module interface {
+	Parameters() []*Value
+}
+
+func ZeroGrad(m Module) {
+	for _, p := range m.Parameters() {
+		p.grad = 0
+	}
+}
+
+type Neuron struct {
+	W      []*Value
+	B      *Value
+	Nonlin bool
+}
+
+func NewNeuron(nin int, nonlin bool, rng *rand.Rand) *Neuron {
+	// Scale the uniform(-1, 1) init by fan-in so deeper stacks stay well conditioned
+	scale := 1 / math.Sqrt(float64(nin))
+	w := make([]*Value, nin)
+	for i := range w {
+		w[i] = NewValue((rng.Float64()*2 - 1) * scale)
+	}
+
+	return &Neuron{W: w, B: NewValue(0), Nonlin: nonlin}
+}
+
+func (n *Neuron) Call(x []*Value) *Value {
+	act := n.B
+	for i, wi := range n.W {
+		act = act.Add(wi.Multiply(x[i]))
+	}
+
+	if n.Nonlin {
+		return act.ReLU()
+	}
+	return act
+}
+
+// Clone returns a neuron with its own parameters holding the same data, so
+// it can be used on another goroutine. See ReduceGrads.
+func (n *Neuron) Clone() *Neuron {
+	w := make([]*Value, len(n.W))
+	for i, wi := range n.W {
+		w[i] = NewValue(wi.data)
+	}
+
+	return &Neuron{W: w, B: NewValue(n.B.data), Nonlin: n.Nonlin}
+}
+
+func (n *Neuron) Parameters() []*Value {
+	return append(append([]*Value{}, n.W...), n.B)
+}
+
+type Layer struct {
+	Neurons []*Neuron
+}
+
+func NewLayer(nin, nout int, nonlin bool, rng *rand.Rand) *Layer {
+	neurons := make([]*Neuron, nout)
+	for i := range neurons {
+		neurons[i] = NewNeuron(nin, nonlin, rng)
+	}
+
+	return &Layer{Neurons: neurons}
+}
+
+func (l *Layer) Call(x []*Value) []*Value {
+	out := make([]*Value, len(l.Neurons))
+	for i, n := range l.Neurons {
+		out[i] = n.Call(x)
+	}
+
+	return out
+}
+
+func (l *Layer) Clone() *Layer {
+	neurons := make([]*Neuron, len(l.Neurons))
+	for i, n := range l.Neurons {
+		neurons[i] = n.Clone()
+	}
+
+	return &Layer{Neurons: neurons}
+}
+
+func (l *Layer) Parameters() []*Value {
+	var params []*Value
+	for _, n := range l.Neurons {
+		params = append(params, n.Parameters()...)
+	}
+
+	return params
+}
+
+type MLP struct {
+	Layers []*Layer
+}
+
+// NewMLP builds a stack of fully connected layers. Every layer but the last
+// applies a ReLU, matching micrograd's MLP.
+func NewMLP(nin int, nouts []int, rng *rand.Rand) *MLP {
+	sizes := append([]int{nin}, nouts...)
+	layers := make([]*Layer, len(nouts))
+	for i := range nouts {
+		layers[i] = NewLayer(sizes[i], sizes[i+1], i != len(nouts)-1, rng)
+	}
+
+	return &MLP{Layers: layers}
+}
+
+func (m *MLP) Call(x []*Value) []*Value {
+	for _, layer := range m.Layers {
+		x = layer.Call(x)
+	}
+
+	return x
+}
+
+// Clone returns an MLP with its own parameters holding the same data, in
+// the same Parameters order.
+func (m *MLP) Clone() *MLP {
+	layers := make([]*Layer, len(m.Layers))
+	for i, l := range m.Layers {
+		layers[i] = l.Clone()
+	}
+
+	return &MLP{Layers: layers}
+}
+
+func (m *MLP) Parameters() []*Value {
+	var params []*Value
+	for _, layer := range m.Layers {
+		params = append(params, layer.Parameters()...)
+	}
+
+	return params
+}
@@ -0,0 +1,60 @@
+package micrograd
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMLPParameters(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMLP(3, []int{4, 4, 1}, rng)
+
+	// (3*4 + 4) + (4*4 + 4) + (4*1 + 1)
+	assert.Len(t, m.Parameters(), 41, "Unexpected parameter count")
+	assert.True(t, m.Layers[0].Neurons[0].Nonlin, "Hidden layers should be nonlinear")
+	assert.False(t, m.Layers[2].Neurons[0].Nonlin, "Output layer should be linear")
+}
+
+func TestNeuronBackward(t *testing.T) {
+	n := &Neuron{W: []*Value{NewValue(2.0), NewValue(-1.0)}, B: NewValue(0.5)}
+	x := []*Value{NewValue(3.0), NewValue(4.0)}
+
+	out := n.Call(x) // out = 2*3 + -1*4 + 0.5
+	out.Backward()
+
+	assert.InDelta(t, 2.5, out.data, 1e-9, "Neuron forward computation mismatch")
+	assert.InDelta(t, 3.0, n.W[0].grad, 1e-9, "Neuron gradient mismatch for w0")
+	assert.InDelta(t, 4.0, n.W[1].grad, 1e-9, "Neuron gradient mismatch for w1")
+	assert.InDelta(t, 1.0, n.B.grad, 1e-9, "Neuron gradient mismatch for b")
+}
+
+func TestMLPTrainsOnTinyProblem(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	m := NewMLP(2, []int{8, 1}, rng)
+
+	xs := [][]float64{{0, 0}, {0, 1}, {1, 0}, {1, 1}}
+	ys := []float64{0, 1, 1, 2}
+
+	lossAt := func() *Value {
+		var losses []*Value
+		for i, x := range xs {
+			pred := m.Call([]*Value{NewValue(x[0]), NewValue(x[1])})[0]
+			losses = append(losses, pred.SubScalar(ys[i]).PowScalar(2))
+		}
+		return Mean(losses)
+	}
+
+	initial := lossAt().data
+	for step := 0; step < 200; step++ {
+		ZeroGrad(m)
+		loss := lossAt()
+		loss.Backward()
+		for _, p := range m.Parameters() {
+			p.data -= 0.05 * p.grad
+		}
+	}
+
+	assert.Less(t, lossAt().data, initial/10, "MLP loss should drop after training")
+}
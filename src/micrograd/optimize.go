@@ -0,0 +1,189 @@
+package micrograd
+
+import "math"
+
+// Optimize returns a tape computing the same output and input gradients with
+// fewer instructions. It folds instructions whose operands are all
+// constants, drops x*1 and x+0, merges chains of constant products and sums
+// such as the x*-1*-1 that Neg().Neg() leaves, shares instructions that
+// compute the same thing, and removes instructions the output no longer
+// depends on. Merging constant chains can change results in the last bit.
+//
+// Leaves that are not inputs count as constants. Functions are never folded
+// or shared.
+func (t *Tape) Optimize() *Tape {
+	n := len(t.data)
+	data := append([]float64(nil), t.data...)
+	konst := make([]bool, n)
+	for s := range konst {
+		konst[s] = true
+	}
+	for _, s := range t.inputs {
+		konst[s] = false
+	}
+	for _, ins := range t.code {
+		konst[ins.out] = false
+	}
+
+	// alias maps every slot to the slot holding the same value; equal
+	// constants all point at the first of them.
+	alias := make([]int32, n)
+	consts := make(map[uint64]int32)
+	constant := func(x float64) int32 {
+		bits := math.Float64bits(x)
+		if s, ok := consts[bits]; ok {
+			return s
+		}
+		s := int32(len(data))
+		data = append(data, x)
+		konst = append(konst, true)
+		alias = append(alias, s)
+		consts[bits] = s
+		return s
+	}
+	for s := range alias {
+		alias[s] = int32(s)
+		if konst[s] {
+			bits := math.Float64bits(data[s])
+			if first, ok := consts[bits]; ok {
+				alias[s] = first
+			} else {
+				consts[bits] = int32(s)
+			}
+		}
+	}
+	isConst := func(s int32, x float64) bool {
+		return konst[s] && data[s] == x
+	}
+
+	type key struct {
+		op   opcode
+		a, b int32
+	}
+	seen := make(map[key]int32)
+	def := make(map[int32]instr)
+	var code []instr
+	var args []int32
+
+	for _, ins := range t.code {
+		if ins.op == opFunc {
+			start := int32(len(args))
+			for _, s := range t.args[ins.a : ins.a+ins.b] {
+				args = append(args, alias[s])
+			}
+			ins.a = start
+			code = append(code, ins)
+			continue
+		}
+
+		a, b := alias[ins.a], int32(0)
+		if ins.op.binary() {
+			b = alias[ins.b]
+		}
+
+		if konst[a] && (!ins.op.binary() || konst[b]) {
+			alias[ins.out] = constant(evalOp(ins.op, data[a], data[b]))
+			continue
+		}
+
+		// Keep constants on the right so the rules below see them there
+		op := ins.op
+		if (op == opAdd || op == opMul) && konst[a] {
+			a, b = b, a
+		}
+		if op == opAdd && konst[b] {
+			op = opAddConst
+		}
+
+		// (x*c1)*c2 is x*(c1*c2), and likewise for sums
+		if prev, ok := def[a]; ok && prev.op == op && konst[prev.b] && konst[b] && (op == opMul || op == opAddConst) {
+			if op == opMul {
+				a, b = prev.a, constant(data[prev.b]*data[b])
+			} else {
+				a, b = prev.a, constant(data[prev.b]+data[b])
+			}
+		}
+
+		if op == opMul && isConst(b, 1) || op == opAddConst && isConst(b, 0) {
+			alias[ins.out] = a
+			continue
+		}
+
+		k := key{op, a, b}
+		if (op == opAdd || op == opMul) && k.a > k.b {
+			k.a, k.b = k.b, k.a
+		}
+		if s, ok := seen[k]; ok {
+			alias[ins.out] = s
+			continue
+		}
+		seen[k] = ins.out
+
+		ins = instr{op: op, out: ins.out, a: a, b: b}
+		def[ins.out] = ins
+		code = append(code, ins)
+	}
+
+	return t.compact(code, args, data, alias[t.output])
+}
+
+// compact builds a tape from code, keeping the instructions output depends
+// on and renumbering the slots they use.
+func (t *Tape) compact(code []instr, args []int32, data []float64, output int32) *Tape {
+	live := map[int32]bool{output: true}
+	var kept []instr
+	for i := len(code) - 1; i >= 0; i-- {
+		ins := code[i]
+		if !live[ins.out] {
+			continue
+		}
+		if ins.op == opFunc {
+			for _, s := range args[ins.a : ins.a+ins.b] {
+				live[s] = true
+			}
+		} else {
+			live[ins.a] = true
+			if ins.op.binary() {
+				live[ins.b] = true
+			}
+		}
+		kept = append(kept, ins)
+	}
+
+	out := &Tape{fns: t.fns, in: make([]float64, len(t.in))}
+	slots := make(map[int32]int32)
+	slot := func(s int32) int32 {
+		if ns, ok := slots[s]; ok {
+			return ns
+		}
+		ns := int32(len(out.data))
+		slots[s] = ns
+		out.data = append(out.data, data[s])
+		return ns
+	}
+
+	for _, s := range t.inputs {
+		out.inputs = append(out.inputs, slot(s))
+	}
+	for i := len(kept) - 1; i >= 0; i-- {
+		ins := kept[i]
+		if ins.op == opFunc {
+			start := int32(len(out.args))
+			for _, s := range args[ins.a : ins.a+ins.b] {
+				out.args = append(out.args, slot(s))
+			}
+			ins.a = start
+		} else {
+			ins.a = slot(ins.a)
+			if ins.op.binary() {
+				ins.b = slot(ins.b)
+			}
+		}
+		ins.out = slot(ins.out)
+		out.code = append(out.code, ins)
+	}
+
+	out.output = slot(output)
+	out.grad = make([]float64, len(out.data))
+	return out
+}
@@ -0,0 +1,107 @@
+package micrograd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertSameTape runs both tapes on each input vector and compares outputs
+// and input gradients.
+func assertSameTape(t *testing.T, want, got *Tape, inputs ...[]float64) {
+	t.Helper()
+	wantGrads := make([]float64, want.NumInputs())
+	gotGrads := make([]float64, got.NumInputs())
+	for _, x := range inputs {
+		assert.InDelta(t, want.Forward(x), got.Forward(x), 1e-12, "output at %v", x)
+		want.Backward()
+		got.Backward()
+		want.Grads(wantGrads)
+		got.Grads(gotGrads)
+		assert.InDeltaSlice(t, wantGrads, gotGrads, 1e-12, "gradients at %v", x)
+	}
+}
+
+func compile(t *testing.T, root *Value, inputs ...*Value) *Tape {
+	t.Helper()
+	tape, err := Compile(root, inputs)
+	require.NoError(t, err)
+	return tape
+}
+
+func TestOptimizeNegChain(t *testing.T) {
+	x := NewValue(3)
+	tape := compile(t, x.Neg().Neg().MulScalar(1).AddScalar(0), x)
+	opt := tape.Optimize()
+
+	assert.Equal(t, 4, tape.Len())
+	assert.Zero(t, opt.Len(), "x*-1*-1*1+0 is x")
+	assertSameTape(t, tape, opt, []float64{3}, []float64{-2})
+}
+
+func TestOptimizeConstantFolding(t *testing.T) {
+	x := NewValue(0.5)
+	c := NewValue(2) // not an input, so a constant
+	out := x.Multiply(c.Exp().Log().PowScalar(2)).Sub(c.MulScalar(3))
+	tape := compile(t, out, x)
+	opt := tape.Optimize()
+
+	assert.Equal(t, 2, opt.Len(), "only x*4 and the subtraction should be left")
+	assertSameTape(t, tape, opt, []float64{0.5}, []float64{-7})
+}
+
+func TestOptimizeCSE(t *testing.T) {
+	a, b := NewValue(2), NewValue(-3)
+	ab := func() *Value { return a.Multiply(b).AddScalar(1).ReLU() }
+	ba := b.Multiply(a).AddScalar(1).ReLU()
+	tape := compile(t, ab().Add(ab()).Add(ba).MulScalar(2).Add(a.MulScalar(2)), a, b)
+	opt := tape.Optimize()
+
+	assert.Equal(t, 14, tape.Len())
+	assert.Equal(t, 8, opt.Len())
+	assertSameTape(t, tape, opt, []float64{2, -3}, []float64{1, 4}, []float64{0, 0})
+}
+
+func TestOptimizeKeepsUnusedInputs(t *testing.T) {
+	a, b := NewValue(1), NewValue(2)
+	tape := compile(t, a.MulScalar(0).Add(NewValue(1)), a, b)
+	opt := tape.Optimize()
+
+	assert.Equal(t, 2, opt.NumInputs())
+	assertSameTape(t, tape, opt, []float64{5, 6})
+}
+
+func TestOptimizeLecture(t *testing.T) {
+	inputs, out := lecture()
+	tape := compile(t, out, inputs...)
+	opt := tape.Optimize()
+
+	assert.Less(t, opt.Len(), tape.Len())
+	assertSameTape(t, tape, opt, []float64{-4, 2}, []float64{3, -1.5}, []float64{0.5, 0.25})
+}
+
+func TestOptimizeMLP(t *testing.T) {
+	p := newMLPProblem(8, []int{8, 8})
+	out, inputs := p.loss()
+	tape := compile(t, out, inputs...)
+	opt := tape.Optimize()
+
+	assert.Less(t, opt.Len(), tape.Len())
+	x := leafData(inputs)
+	shifted := make([]float64, len(x))
+	for i := range x {
+		shifted[i] = x[i] * 0.9
+	}
+	assertSameTape(t, tape, opt, x, shifted)
+}
+
+func TestOptimizeFunction(t *testing.T) {
+	a := NewValue(0.3)
+	s := Apply(sigmoid{}, a.MulScalar(1))
+	tape := compile(t, s.Add(Apply(sigmoid{}, a)), a)
+	opt := tape.Optimize()
+
+	assert.Equal(t, 3, opt.Len(), "Functions are not shared")
+	assertSameTape(t, tape, opt, []float64{0.3}, []float64{-2})
+}
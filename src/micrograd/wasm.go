@@ -0,0 +1,284 @@
+package micrograd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// WriteWasm writes the tape as a WebAssembly module. The host writes the
+// inputs as little-endian float64s at the start of the exported memory and
+// calls one of two exported functions, both () -> f64 returning the output:
+//
+//	forward  computes the output only
+//	gradient also writes the gradient of each input after the inputs
+//
+// The exported i32 global "inputs" holds the number of inputs. The module
+// imports exp, log and pow, each over f64, from "env". The tape is optimized
+// first. Tapes containing Functions cannot be exported.
+func (t *Tape) WriteWasm(w io.Writer) error {
+	t = t.Optimize()
+	for _, ins := range t.code {
+		if ins.op == opFunc {
+			return fmt.Errorf("wasm: Function %s has no wasm translation", FunctionName(t.fns[ins.fn]))
+		}
+	}
+
+	var m bytes.Buffer
+	m.WriteString("\x00asm")
+	m.Write([]byte{1, 0, 0, 0})
+
+	// Types: 0 is (f64) -> f64, 1 is (f64, f64) -> f64, 2 is () -> f64
+	section(&m, 1, func(b *bytes.Buffer) {
+		uleb(b, 3)
+		b.Write([]byte{0x60, 1, wasmF64, 1, wasmF64})
+		b.Write([]byte{0x60, 2, wasmF64, wasmF64, 1, wasmF64})
+		b.Write([]byte{0x60, 0, 1, wasmF64})
+	})
+	section(&m, 2, func(b *bytes.Buffer) {
+		uleb(b, 3)
+		for _, imp := range []struct {
+			name string
+			typ  uint64
+		}{{"exp", 0}, {"log", 0}, {"pow", 1}} {
+			wasmName(b, "env")
+			wasmName(b, imp.name)
+			b.WriteByte(0) // function
+			uleb(b, imp.typ)
+		}
+	})
+	section(&m, 3, func(b *bytes.Buffer) {
+		uleb(b, 2)
+		uleb(b, 2)
+		uleb(b, 2)
+	})
+	section(&m, 5, func(b *bytes.Buffer) {
+		pages := (16*len(t.inputs) + 65535) / 65536
+		uleb(b, 1)
+		b.WriteByte(0) // no maximum
+		uleb(b, uint64(max(pages, 1)))
+	})
+	section(&m, 6, func(b *bytes.Buffer) {
+		uleb(b, 1)
+		b.Write([]byte{0x7f, 0}) // immutable i32
+		b.WriteByte(0x41)
+		sleb(b, int64(len(t.inputs)))
+		b.WriteByte(0x0b)
+	})
+	section(&m, 7, func(b *bytes.Buffer) {
+		uleb(b, 4)
+		wasmName(b, "memory")
+		b.Write([]byte{2, 0})
+		wasmName(b, "inputs")
+		b.Write([]byte{3, 0})
+		wasmName(b, "forward")
+		b.Write([]byte{0, wasmForward})
+		wasmName(b, "gradient")
+		b.Write([]byte{0, wasmGradient})
+	})
+	section(&m, 10, func(b *bytes.Buffer) {
+		uleb(b, 2)
+		t.wasmBody(b, false)
+		t.wasmBody(b, true)
+	})
+
+	_, err := w.Write(m.Bytes())
+	return err
+}
+
+const (
+	wasmF64 = 0x7c
+
+	// Function indices: the three imports come first
+	wasmExp      = 0
+	wasmLog      = 1
+	wasmPow      = 2
+	wasmForward  = 3
+	wasmGradient = 4
+)
+
+// wasmBody writes one function body. Slot s lives in local s and, when
+// computing gradients, its gradient in local len(data)+s.
+func (t *Tape) wasmBody(w *bytes.Buffer, gradient bool) {
+	n := int32(len(t.data))
+	var b bytes.Buffer
+	locals := uint64(n)
+	if gradient {
+		locals *= 2
+	}
+	uleb(&b, 1)
+	uleb(&b, locals)
+	b.WriteByte(wasmF64)
+
+	get := func(s int32) { b.WriteByte(0x20); uleb(&b, uint64(s)) }
+	set := func(s int32) { b.WriteByte(0x21); uleb(&b, uint64(s)) }
+	f64 := func(x float64) {
+		b.WriteByte(0x44)
+		binary.Write(&b, binary.LittleEndian, x)
+	}
+	call := func(fn uint64) { b.WriteByte(0x10); uleb(&b, fn) }
+	memarg := func(offset int) { uleb(&b, 3); uleb(&b, uint64(offset)) }
+	op := func(code byte) { b.WriteByte(code) }
+	const (
+		add, sub, mul, div, fmax = 0xa0, 0xa1, 0xa2, 0xa3, 0xa5
+		ne, gt                   = 0x62, 0x64
+	)
+	// when runs body only if slot s compared with 0 by cmp holds.
+	when := func(s int32, cmp byte, body func()) {
+		get(s)
+		f64(0)
+		op(cmp)
+		b.Write([]byte{0x04, 0x40})
+		body()
+		op(0x0b)
+	}
+
+	input := make(map[int32]bool, len(t.inputs))
+	for i, s := range t.inputs {
+		input[s] = true
+		b.Write([]byte{0x41, 0}) // i32.const 0
+		op(0x2b)                 // f64.load
+		memarg(8 * i)
+		set(s)
+	}
+	computed := make(map[int32]bool, len(t.code))
+	for _, ins := range t.code {
+		computed[ins.out] = true
+	}
+	for s := int32(0); s < n; s++ {
+		if !input[s] && !computed[s] {
+			f64(t.data[s])
+			set(s)
+		}
+	}
+
+	for _, ins := range t.code {
+		get(ins.a)
+		switch ins.op {
+		case opAdd, opAddConst:
+			get(ins.b)
+			op(add)
+		case opMul:
+			get(ins.b)
+			op(mul)
+		case opPow:
+			get(ins.b)
+			call(wasmPow)
+		case opReLU:
+			f64(0)
+			op(fmax)
+		case opExp:
+			call(wasmExp)
+		case opLog:
+			call(wasmLog)
+		}
+		set(ins.out)
+	}
+
+	if gradient {
+		g := func(s int32) int32 { return n + s }
+		// acc adds the value pushed by push to slot s's gradient.
+		acc := func(s int32, push func()) {
+			get(g(s))
+			push()
+			op(add)
+			set(g(s))
+		}
+		times := func(s, gout int32) func() {
+			return func() { get(s); get(gout); op(mul) }
+		}
+
+		f64(1)
+		set(g(t.output))
+		for i := len(t.code) - 1; i >= 0; i-- {
+			ins := t.code[i]
+			gout := g(ins.out)
+			switch ins.op {
+			case opAdd:
+				acc(ins.a, func() { get(gout) })
+				acc(ins.b, func() { get(gout) })
+			case opAddConst:
+				acc(ins.a, func() { get(gout) })
+			case opMul:
+				acc(ins.a, times(ins.b, gout))
+				acc(ins.b, times(ins.a, gout))
+			case opPow:
+				// Same guards as Value.Pow
+				when(ins.a, ne, func() {
+					acc(ins.a, func() {
+						get(ins.b)
+						get(ins.a)
+						get(ins.b)
+						f64(1)
+						op(sub)
+						call(wasmPow)
+						op(mul)
+						get(gout)
+						op(mul)
+					})
+				})
+				when(ins.a, gt, func() {
+					acc(ins.b, func() {
+						get(ins.a)
+						call(wasmLog)
+						get(ins.out)
+						op(mul)
+						get(gout)
+						op(mul)
+					})
+				})
+			case opReLU:
+				when(ins.a, gt, func() { acc(ins.a, func() { get(gout) }) })
+			case opExp:
+				acc(ins.a, times(ins.out, gout))
+			case opLog:
+				acc(ins.a, func() { get(gout); get(ins.a); op(div) })
+			}
+		}
+
+		for i, s := range t.inputs {
+			b.Write([]byte{0x41, 0})
+			get(g(s))
+			op(0x39) // f64.store
+			memarg(8 * (len(t.inputs) + i))
+		}
+	}
+
+	get(t.output)
+	op(0x0b)
+
+	uleb(w, uint64(b.Len()))
+	w.Write(b.Bytes())
+}
+
+// section writes a wasm section with its id and byte length.
+func section(w *bytes.Buffer, id byte, contents func(*bytes.Buffer)) {
+	var b bytes.Buffer
+	contents(&b)
+	w.WriteByte(id)
+	uleb(w, uint64(b.Len()))
+	w.Write(b.Bytes())
+}
+
+func wasmName(w *bytes.Buffer, name string) {
+	uleb(w, uint64(len(name)))
+	w.WriteString(name)
+}
+
+func uleb(w *bytes.Buffer, x uint64) {
+	w.Write(binary.AppendUvarint(nil, x))
+}
+
+// sleb is signed LEB128, which wasm uses for integer constants.
+func sleb(w *bytes.Buffer, x int64) {
+	for {
+		c := byte(x & 0x7f)
+		x >>= 7
+		if x == 0 && c&0x40 == 0 || x == -1 && c&0x40 != 0 {
+			w.WriteByte(c)
+			return
+		}
+		w.WriteByte(c | 0x80)
+	}
+}
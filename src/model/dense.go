@@ -0,0 +1,256 @@
+package model
+
+import (
+	"math"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/gemm"
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// DenseLoss is Loss computed a layer at a time over the whole batch: each
+// layer is one gemm.Gemm in the forward pass and two in the backward pass.
+// The result is a single graph node over Parameters, with the same value
+// and gradients as Loss up to the order sums are taken in. Grad with
+// createGraph cannot differentiate it twice.
+func (m *Model) DenseLoss(contexts [][]int, targets []int) *micrograd.Value {
+	fn := &denseLoss{model: m, contexts: contexts, targets: targets}
+	return micrograd.Apply(fn, m.Parameters()...)
+}
+
+// LossData is the value of Loss, computed like DenseLoss but on the
+// parameters' data, so no graph is built and the process-wide
+// micrograd.NoGrad switch is left alone.
+func (m *Model) LossData(contexts [][]int, targets []int) float64 {
+	fn := &denseLoss{model: m, contexts: contexts, targets: targets}
+	return fn.Forward(m.parameterData())
+}
+
+// Logits returns the next token logits for each context, VocabSize per
+// context, without building a graph.
+func (m *Model) Logits(contexts [][]int) []float64 {
+	fn := &denseLoss{model: m, contexts: contexts}
+	fn.saved = fn.forward(m.parameterData())
+	return fn.logits()
+}
+
+func (m *Model) parameterData() []float64 {
+	params := m.Parameters()
+	data := make([]float64, len(params))
+	for i, p := range params {
+		data[i] = p.Data()
+	}
+	return data
+}
+
+// denseLoss is the Function behind DenseLoss. Its inputs are the
+// parameters in NamedParameters order. Forward keeps the activations for
+// Backward in the model's activation dtype, so float16 activations take a
+// quarter of the memory of float64 ones.
+type denseLoss struct {
+	model    *Model
+	contexts [][]int
+	targets  []int
+	saved    *denseActs // from the last Forward
+}
+
+func (*denseLoss) Name() string { return "dense loss" }
+
+// layerView is one layer's weight and bias within the flat inputs.
+type layerView struct {
+	w, b     []float64
+	nin, out int
+	relu     bool
+}
+
+// denseActs holds the input to every layer and the last layer's output,
+// batch rows by features, row major, and which units of each ReLU layer
+// were active.
+type denseActs struct {
+	h      []*dtype.Buffer // h[0] is the embedded input, h[l+1] the output of layer l
+	active [][]bool        // nil for layers without ReLU
+}
+
+// Bytes is the memory the saved activations take.
+func (a *denseActs) Bytes() int {
+	var n int
+	for _, h := range a.h {
+		n += h.Bytes()
+	}
+	for _, m := range a.active {
+		n += len(m)
+	}
+	return n
+}
+
+// layers splits params into the embedding table and per layer views.
+func (f *denseLoss) layers(params []float64) ([]float64, []layerView) {
+	cfg := f.model.Config
+	off := cfg.VocabSize * cfg.EmbedDim
+	embedding := params[:off]
+
+	views := make([]layerView, len(f.model.MLP.Layers))
+	for i, layer := range f.model.MLP.Layers {
+		out, nin := len(layer.Neurons), len(layer.Neurons[0].W)
+		views[i] = layerView{
+			w:    params[off : off+out*nin],
+			b:    params[off+out*nin : off+out*nin+out],
+			nin:  nin,
+			out:  out,
+			relu: layer.Neurons[0].Nonlin,
+		}
+		off += out*nin + out
+	}
+
+	return embedding, views
+}
+
+// store saves x in the activation dtype and rounds x to what was stored,
+// like Model.cast.
+func (f *denseLoss) store(x []float64) *dtype.Buffer {
+	b := dtype.NewBuffer(f.model.Activations, len(x))
+	b.Store(x)
+	b.Load(x)
+	return b
+}
+
+func (f *denseLoss) forward(params []float64) *denseActs {
+	cfg := f.model.Config
+	batch, dim := len(f.contexts), cfg.EmbedDim
+	embedding, views := f.layers(params)
+
+	x := make([]float64, 0, batch*cfg.ContextSize*dim)
+	for _, ctx := range f.contexts {
+		for _, id := range ctx {
+			x = append(x, embedding[id*dim:(id+1)*dim]...)
+		}
+	}
+
+	acts := &denseActs{h: []*dtype.Buffer{f.store(x)}, active: make([][]bool, len(views))}
+	for i, l := range views {
+		h := make([]float64, batch*l.out)
+		for j := 0; j < batch; j++ {
+			copy(h[j*l.out:(j+1)*l.out], l.b)
+		}
+		// h = x·Wᵀ + b, with W stored one neuron per row
+		gemm.Gemm(gemm.NoTrans, gemm.Trans, batch, l.out, l.nin, x, l.w, 1, h)
+
+		if l.relu {
+			acts.active[i] = make([]bool, len(h))
+			for j, v := range h {
+				acts.active[i][j] = v > 0
+				h[j] = max(v, 0)
+			}
+		}
+		acts.h = append(acts.h, f.store(h))
+		x = h
+	}
+
+	return acts
+}
+
+func (f *denseLoss) Forward(inputs []float64) float64 {
+	f.saved = f.forward(inputs)
+	logits := f.logits()
+	vocab := f.model.Config.VocabSize
+
+	var sum float64
+	for i, t := range f.targets {
+		sum += crossEntropy(logits[i*vocab:(i+1)*vocab], t)
+	}
+	return sum / float64(len(f.targets))
+}
+
+// logits loads the saved output of the last layer.
+func (f *denseLoss) logits() []float64 {
+	out := f.saved.h[len(f.saved.h)-1]
+	logits := make([]float64, out.Len())
+	out.Load(logits)
+	return logits
+}
+
+// Backward uses the activations Forward saved, so the parameters must not
+// change between the two.
+func (f *denseLoss) Backward(inputs []float64, out, gradOut float64) []float64 {
+	cfg := f.model.Config
+	batch, vocab, dim := len(f.contexts), cfg.VocabSize, cfg.EmbedDim
+	round := f.model.Activations.RoundSlice
+	_, views := f.layers(inputs)
+
+	grads := make([]float64, len(inputs))
+	_, gradViews := f.layers(grads)
+
+	// d mean(CE) / d logits is (softmax - onehot) / batch
+	logits := f.logits()
+	dh := make([]float64, batch*vocab)
+	for i, t := range f.targets {
+		row := logits[i*vocab : (i+1)*vocab]
+		hi := math.Inf(-1)
+		for _, l := range row {
+			hi = max(hi, l)
+		}
+		var sum float64
+		for _, l := range row {
+			sum += math.Exp(l - hi)
+		}
+		for j, l := range row {
+			p := math.Exp(l-hi) / sum
+			if j == t {
+				p--
+			}
+			dh[i*vocab+j] = p * gradOut / float64(batch)
+		}
+	}
+
+	for li := len(views) - 1; li >= 0; li-- {
+		l, g := views[li], gradViews[li]
+		// The cast after the layer rounds the gradient on its way back
+		round(dh)
+		for i, on := range f.saved.active[li] {
+			if !on {
+				dh[i] = 0
+			}
+		}
+
+		for i := 0; i < batch; i++ {
+			for j, d := range dh[i*l.out : (i+1)*l.out] {
+				g.b[j] += d
+			}
+		}
+		// dW = dhᵀ·x and dx = dh·W
+		x := make([]float64, batch*l.nin)
+		f.saved.h[li].Load(x)
+		gemm.Gemm(gemm.Trans, gemm.NoTrans, l.out, l.nin, batch, dh, x, 0, g.w)
+		dx := make([]float64, batch*l.nin)
+		gemm.Gemm(gemm.NoTrans, gemm.NoTrans, batch, l.nin, l.out, dh, l.w, 0, dx)
+		dh = dx
+	}
+	round(dh)
+
+	embedding := grads[:vocab*dim]
+	for i, ctx := range f.contexts {
+		for k, id := range ctx {
+			off := (i*cfg.ContextSize + k) * dim
+			for j := range dim {
+				embedding[id*dim+j] += dh[off+j]
+			}
+		}
+	}
+
+	return grads
+}
+
+// crossEntropy is -log softmax(logits)[target], shifted by the largest
+// logit as micrograd.CrossEntropy does.
+func crossEntropy(logits []float64, target int) float64 {
+	hi := math.Inf(-1)
+	for _, l := range logits {
+		hi = max(hi, l)
+	}
+
+	var sum float64
+	for _, l := range logits {
+		sum += math.Exp(l - hi)
+	}
+	return math.Log(sum) - (logits[target] - hi)
+}
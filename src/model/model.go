@@ -0,0 +1,202 @@
+package model
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// Config describes a character level MLP language model in the style of
+// Bengio et al. 2003: the embeddings of the previous ContextSize tokens are
+// concatenated and fed through a stack of hidden layers to predict the next
+// token.
+type Config struct {
+	VocabSize   int   `json:"vocab_size" yaml:"vocab_size"`
+	ContextSize int   `json:"context_size" yaml:"context_size"`
+	EmbedDim    int   `json:"embed_dim" yaml:"embed_dim"`
+	Hidden      []int `json:"hidden" yaml:"hidden"`
+}
+
+func (c Config) Validate() error {
+	if c.VocabSize <= 0 {
+		return fmt.Errorf("vocab_size must be positive, got %d", c.VocabSize)
+	}
+	if c.ContextSize <= 0 {
+		return fmt.Errorf("context_size must be positive, got %d", c.ContextSize)
+	}
+	if c.EmbedDim <= 0 {
+		return fmt.Errorf("embed_dim must be positive, got %d", c.EmbedDim)
+	}
+	for i, h := range c.Hidden {
+		if h <= 0 {
+			return fmt.Errorf("hidden[%d] must be positive, got %d", i, h)
+		}
+	}
+
+	return nil
+}
+
+type Model struct {
+	Config    Config
+	Embedding [][]*micrograd.Value
+	MLP       *micrograd.MLP
+	// Activations is the precision the inputs and outputs of every layer
+	// are stored in, and so the gradients flowing back through them. The
+	// zero value, dtype.Float64, leaves them unrounded.
+	Activations dtype.DType
+}
+
+// Param is a named, shaped view over model parameters, used for
+// checkpointing. Values are stored row major.
+type Param struct {
+	Name   string
+	Shape  []int
+	Values []*micrograd.Value
+}
+
+func New(cfg Config, rng *rand.Rand) (*Model, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	embedding := make([][]*micrograd.Value, cfg.VocabSize)
+	for i := range embedding {
+		row := make([]*micrograd.Value, cfg.EmbedDim)
+		for j := range row {
+			row[j] = micrograd.NewValue(rng.NormFloat64())
+		}
+		embedding[i] = row
+	}
+
+	nouts := append(append([]int{}, cfg.Hidden...), cfg.VocabSize)
+
+	return &Model{
+		Config:    cfg,
+		Embedding: embedding,
+		MLP:       micrograd.NewMLP(cfg.ContextSize*cfg.EmbedDim, nouts, rng),
+	}, nil
+}
+
+// Clone returns a model with its own parameters holding the same data, for
+// use on another goroutine.
+func (m *Model) Clone() *Model {
+	embedding := make([][]*micrograd.Value, len(m.Embedding))
+	for i, row := range m.Embedding {
+		embedding[i] = make([]*micrograd.Value, len(row))
+		for j, v := range row {
+			embedding[i][j] = micrograd.NewValue(v.Data())
+		}
+	}
+
+	return &Model{Config: m.Config, Embedding: embedding, MLP: m.MLP.Clone(), Activations: m.Activations}
+}
+
+// Forward returns the next token logits for a context of exactly
+// ContextSize token ids.
+func (m *Model) Forward(context []int) []*micrograd.Value {
+	x := make([]*micrograd.Value, 0, m.Config.ContextSize*m.Config.EmbedDim)
+	for _, id := range context {
+		x = append(x, m.Embedding[id]...)
+	}
+	if m.Activations == dtype.Float64 {
+		return m.MLP.Call(x)
+	}
+
+	x = m.cast(x)
+	for _, layer := range m.MLP.Layers {
+		x = m.cast(layer.Call(x))
+	}
+	return x
+}
+
+// cast rounds x to the activation precision.
+func (m *Model) cast(x []*micrograd.Value) []*micrograd.Value {
+	fn := cast{m.Activations}
+	out := make([]*micrograd.Value, len(x))
+	for i, v := range x {
+		out[i] = micrograd.Apply(fn, v)
+	}
+
+	return out
+}
+
+// cast stores a value in a lower precision. The gradient passes straight
+// through but is rounded the same way, so it can underflow to zero or
+// overflow to Inf like a real low precision activation gradient.
+type cast struct {
+	dtype dtype.DType
+}
+
+func (c cast) Name() string {
+	return "cast " + c.dtype.String()
+}
+
+func (c cast) Forward(inputs []float64) float64 {
+	return c.dtype.Round(inputs[0])
+}
+
+func (c cast) Backward(inputs []float64, out, gradOut float64) []float64 {
+	return []float64{c.dtype.Round(gradOut)}
+}
+
+func (c cast) BackwardGraph(inputs []*micrograd.Value, out, gradOut *micrograd.Value) []*micrograd.Value {
+	return []*micrograd.Value{micrograd.Apply(c, gradOut)}
+}
+
+// Loss is the mean cross entropy of predicting targets[i] from contexts[i].
+func (m *Model) Loss(contexts [][]int, targets []int) *micrograd.Value {
+	losses := make([]*micrograd.Value, len(contexts))
+	for i, ctx := range contexts {
+		losses[i] = micrograd.CrossEntropy(m.Forward(ctx), targets[i])
+	}
+
+	return micrograd.Mean(losses)
+}
+
+// Context left pads ids with token 0, or keeps only the most recent tokens,
+// so the result is exactly ContextSize long.
+func (m *Model) Context(ids []int) []int {
+	n := m.Config.ContextSize
+	if len(ids) >= n {
+		return append([]int{}, ids[len(ids)-n:]...)
+	}
+
+	ctx := make([]int, n-len(ids), n)
+	return append(ctx, ids...)
+}
+
+func (m *Model) Parameters() []*micrograd.Value {
+	var params []*micrograd.Value
+	for _, p := range m.NamedParameters() {
+		params = append(params, p.Values...)
+	}
+
+	return params
+}
+
+func (m *Model) NamedParameters() []Param {
+	embedding := make([]*micrograd.Value, 0, m.Config.VocabSize*m.Config.EmbedDim)
+	for _, row := range m.Embedding {
+		embedding = append(embedding, row...)
+	}
+
+	params := []Param{{Name: "embedding", Shape: []int{m.Config.VocabSize, m.Config.EmbedDim}, Values: embedding}}
+	for i, layer := range m.MLP.Layers {
+		nout, nin := len(layer.Neurons), len(layer.Neurons[0].W)
+		weights := make([]*micrograd.Value, 0, nout*nin)
+		biases := make([]*micrograd.Value, 0, nout)
+		for _, n := range layer.Neurons {
+			weights = append(weights, n.W...)
+			biases = append(biases, n.B)
+		}
+
+		params = append(params,
+			Param{Name: fmt.Sprintf("layers.%d.weight", i), Shape: []int{nout, nin}, Values: weights},
+			Param{Name: fmt.Sprintf("layers.%d.bias", i), Shape: []int{nout}, Values: biases},
+		)
+	}
+
+	return params
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+func tinyConfig() Config {
+	return Config{VocabSize: 5, ContextSize: 3, EmbedDim: 2, Hidden: []int{4}}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	_, err := New(Config{VocabSize: 5, ContextSize: 0, EmbedDim: 2}, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
+
+func TestForwardShape(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	logits := m.Forward([]int{0, 1, 2})
+	assert.Len(t, logits, 5, "Forward should return one logit per vocabulary entry")
+}
+
+func TestNamedParametersCoverAllParameters(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	named := m.NamedParameters()
+	names := make([]string, len(named))
+	for i, p := range named {
+		names[i] = p.Name
+		size := 1
+		for _, d := range p.Shape {
+			size *= d
+		}
+		assert.Len(t, p.Values, size, "Shape of %s should match its values", p.Name)
+	}
+
+	assert.Equal(t, []string{"embedding", "layers.0.weight", "layers.0.bias", "layers.1.weight", "layers.1.bias"}, names)
+	// 5*2 + (6*4 + 4) + (4*5 + 5)
+	assert.Len(t, m.Parameters(), 63)
+}
+
+func TestClone(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	clone := m.Clone()
+
+	params, cloned := m.Parameters(), clone.Parameters()
+	require.Len(t, cloned, len(params))
+	for i := range params {
+		assert.Equal(t, params[i].Data(), cloned[i].Data())
+		assert.NotSame(t, params[i], cloned[i])
+	}
+	assert.Equal(t, m.Forward([]int{0, 1, 2})[0].Data(), clone.Forward([]int{0, 1, 2})[0].Data())
+}
+
+func TestActivationsAreRounded(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	full := m.Forward([]int{0, 1, 2})
+
+	m.Activations = dtype.Float16
+	half := m.Forward([]int{0, 1, 2})
+	for i, logit := range half {
+		assert.Equal(t, dtype.Float16.Round(logit.Data()), logit.Data(), "Logit %d should be a float16", i)
+		assert.InDelta(t, full[i].Data(), logit.Data(), 1e-2)
+	}
+
+	// A tiny loss gradient survives in float64 but underflows once it is
+	// rounded to float16 on the way back through the casts
+	micrograd.CrossEntropy(full, 3).MulScalar(1e-9).Backward()
+	assert.NotZero(t, gradSum(m.Parameters()))
+
+	micrograd.ZeroGrad(m)
+	micrograd.CrossEntropy(half, 3).MulScalar(1e-9).Backward()
+	assert.Zero(t, gradSum(m.Parameters()))
+}
+
+func gradSum(params []*micrograd.Value) float64 {
+	var sum float64
+	for _, p := range params {
+		sum += math.Abs(p.Grad())
+	}
+	return sum
+}
+
+func TestContextPadsAndTruncates(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0, 0, 4}, m.Context([]int{4}))
+	assert.Equal(t, []int{2, 3, 4}, m.Context([]int{1, 2, 3, 4}))
+}
+
+func TestLossDecreases(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	// Always predict 3 after seeing 0, 1, 2
+	contexts := [][]int{{0, 1, 2}}
+	targets := []int{3}
+
+	initial := m.Loss(contexts, targets).Data()
+	assert.InDelta(t, math.Log(5), initial, 2.0, "Initial loss should be near uniform")
+
+	for step := 0; step < 50; step++ {
+		micrograd.ZeroGrad(m)
+		loss := m.Loss(contexts, targets)
+		loss.Backward()
+		for _, p := range m.Parameters() {
+			p.SetData(p.Data() - 0.1*p.Grad())
+		}
+	}
+
+	assert.Less(t, m.Loss(contexts, targets).Data(), initial/2, "Loss should decrease when overfitting one example")
+}
+
+func TestDenseLossMatchesLoss(t *testing.T) {
+	contexts := [][]int{{0, 1, 2}, {4, 4, 0}, {3, 1, 1}}
+	targets := []int{3, 0, 2}
+
+	for _, activations := range []dtype.DType{dtype.Float64, dtype.Float16} {
+		t.Run(activations.String(), func(t *testing.T) {
+			m, err := New(Config{VocabSize: 5, ContextSize: 3, EmbedDim: 2, Hidden: []int{4, 3}}, rand.New(rand.NewSource(1)))
+			require.NoError(t, err)
+			m.Activations = activations
+
+			loss := m.Loss(contexts, targets)
+			loss.Backward()
+			want := make([]float64, len(m.Parameters()))
+			for i, p := range m.Parameters() {
+				want[i] = p.Grad()
+			}
+
+			micrograd.ZeroGrad(m)
+			dense := m.DenseLoss(contexts, targets)
+			dense.Backward()
+			assert.InDelta(t, loss.Data(), dense.Data(), 1e-12)
+			for i, p := range m.Parameters() {
+				assert.InDelta(t, want[i], p.Grad(), 1e-12, "parameter %d", i)
+			}
+		})
+	}
+}
+
+// DenseLoss keeps the activations Backward needs in the activation dtype.
+func TestDenseLossActivationMemory(t *testing.T) {
+	cfg := Config{VocabSize: 5, ContextSize: 3, EmbedDim: 2, Hidden: []int{4, 3}}
+	contexts := [][]int{{0, 1, 2}, {4, 4, 0}}
+	m, err := New(cfg, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	params := make([]float64, len(m.Parameters()))
+	for i, p := range m.Parameters() {
+		params[i] = p.Data()
+	}
+	// Per context: the 3·2 embedded inputs, 4 + 3 hidden units and 5
+	// logits, plus a byte for each hidden unit's ReLU mask
+	values, masks := 2*(6+4+3+5), 2*(4+3)
+
+	for _, d := range []dtype.DType{dtype.Float64, dtype.Float32, dtype.Float16, dtype.BFloat16} {
+		m.Activations = d
+		fn := &denseLoss{model: m, contexts: contexts, targets: []int{3, 0}}
+		fn.Forward(params)
+		assert.Equal(t, values*d.Size()+masks, fn.saved.Bytes(), "%v", d)
+	}
+}
+
+// Logits and LossData compute without a graph and agree with Forward and
+// Loss.
+func TestLogitsAndLossData(t *testing.T) {
+	m, err := New(tinyConfig(), rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	contexts := [][]int{{0, 1, 2}, {4, 4, 0}}
+
+	logits := m.Logits(contexts)
+	require.Len(t, logits, 2*5)
+	for i, ctx := range contexts {
+		for j, want := range m.Forward(ctx) {
+			assert.InDelta(t, want.Data(), logits[i*5+j], 1e-12)
+		}
+	}
+	assert.InDelta(t, m.Loss(contexts, []int{3, 0}).Data(), m.LossData(contexts, []int{3, 0}), 1e-12)
+}
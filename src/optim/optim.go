@@ -0,0 +1,137 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// Optimizer updates parameters in place from their accumulated gradients.
+type Optimizer interface {
+	Step(lr float64)
+}
+
+type Config struct {
+	Name        string  `yaml:"name"`
+	LR          float64 `yaml:"lr"`
+	Momentum    float64 `yaml:"momentum"`
+	Beta1       float64 `yaml:"beta1"`
+	Beta2       float64 `yaml:"beta2"`
+	Eps         float64 `yaml:"eps"`
+	WeightDecay float64 `yaml:"weight_decay"`
+	GradClip    float64 `yaml:"grad_clip"`
+}
+
+func New(cfg Config, params []*micrograd.Value) (Optimizer, error) {
+	switch cfg.Name {
+	case "sgd":
+		return NewSGD(params, cfg.Momentum, cfg.WeightDecay), nil
+	case "adamw", "":
+		beta1, beta2, eps := cfg.Beta1, cfg.Beta2, cfg.Eps
+		if beta1 == 0 {
+			beta1 = 0.9
+		}
+		if beta2 == 0 {
+			beta2 = 0.999
+		}
+		if eps == 0 {
+			eps = 1e-8
+		}
+		return NewAdamW(params, beta1, beta2, eps, cfg.WeightDecay), nil
+	default:
+		return nil, fmt.Errorf("unknown optimizer %q", cfg.Name)
+	}
+}
+
+type SGD struct {
+	params      []*micrograd.Value
+	momentum    float64
+	weightDecay float64
+	velocity    []float64
+}
+
+func NewSGD(params []*micrograd.Value, momentum, weightDecay float64) *SGD {
+	return &SGD{
+		params:      params,
+		momentum:    momentum,
+		weightDecay: weightDecay,
+		velocity:    make([]float64, len(params)),
+	}
+}
+
+func (o *SGD) Step(lr float64) {
+	for i, p := range o.params {
+		g := p.Grad() + o.weightDecay*p.Data()
+		o.velocity[i] = o.momentum*o.velocity[i] + g
+		p.SetData(p.Data() - lr*o.velocity[i])
+	}
+}
+
+// AdamW is Adam with decoupled weight decay (Loshchilov & Hutter, 2019).
+type AdamW struct {
+	params      []*micrograd.Value
+	beta1       float64
+	beta2       float64
+	eps         float64
+	weightDecay float64
+	m           []float64
+	v           []float64
+	t           int
+}
+
+func NewAdamW(params []*micrograd.Value, beta1, beta2, eps, weightDecay float64) *AdamW {
+	return &AdamW{
+		params:      params,
+		beta1:       beta1,
+		beta2:       beta2,
+		eps:         eps,
+		weightDecay: weightDecay,
+		m:           make([]float64, len(params)),
+		v:           make([]float64, len(params)),
+	}
+}
+
+func (o *AdamW) Step(lr float64) {
+	o.t++
+	bias1 := 1 - math.Pow(o.beta1, float64(o.t))
+	bias2 := 1 - math.Pow(o.beta2, float64(o.t))
+
+	for i, p := range o.params {
+		g := p.Grad()
+		o.m[i] = o.beta1*o.m[i] + (1-o.beta1)*g
+		o.v[i] = o.beta2*o.v[i] + (1-o.beta2)*g*g
+
+		mHat := o.m[i] / bias1
+		vHat := o.v[i] / bias2
+		data := p.Data() * (1 - lr*o.weightDecay)
+		p.SetData(data - lr*mHat/(math.Sqrt(vHat)+o.eps))
+	}
+}
+
+// GradNorm returns the global L2 norm of the parameter gradients.
+func GradNorm(params []*micrograd.Value) float64 {
+	var sum float64
+	for _, p := range params {
+		sum += p.Grad() * p.Grad()
+	}
+
+	return math.Sqrt(sum)
+}
+
+// ClipGradNorm rescales gradients so their global L2 norm is at most
+// maxNorm and returns the norm before clipping. A maxNorm of zero disables
+// clipping.
+func ClipGradNorm(params []*micrograd.Value, maxNorm float64) float64 {
+	norm := GradNorm(params)
+	if maxNorm <= 0 || norm <= maxNorm {
+		return norm
+	}
+
+	scale := maxNorm / norm
+	for _, p := range params {
+		p.SetGrad(p.Grad() * scale)
+	}
+
+	return norm
+}
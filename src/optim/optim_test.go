@@ -0,0 +1,100 @@
+package optim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// minimize runs the optimizer on f(x) = (x - 3)^2 starting from x = 0.
+func minimize(t *testing.T, cfg Config, steps int) float64 {
+	x := micrograd.NewValue(0)
+	opt, err := New(cfg, []*micrograd.Value{x})
+	require.NoError(t, err)
+
+	for i := 0; i < steps; i++ {
+		x.SetGrad(0)
+		x.SubScalar(3).PowScalar(2).Backward()
+		opt.Step(cfg.LR)
+	}
+
+	return x.Data()
+}
+
+func TestSGDConverges(t *testing.T) {
+	assert.InDelta(t, 3.0, minimize(t, Config{Name: "sgd", LR: 0.1}, 100), 1e-3)
+}
+
+func TestSGDMomentumConverges(t *testing.T) {
+	assert.InDelta(t, 3.0, minimize(t, Config{Name: "sgd", LR: 0.05, Momentum: 0.9}, 200), 1e-3)
+}
+
+func TestAdamWConverges(t *testing.T) {
+	assert.InDelta(t, 3.0, minimize(t, Config{Name: "adamw", LR: 0.1}, 500), 1e-2)
+}
+
+func TestAdamWFirstStepIsLR(t *testing.T) {
+	// With bias correction the first Adam update has magnitude lr
+	x := micrograd.NewValue(1.0)
+	x.SetGrad(5.0)
+	NewAdamW([]*micrograd.Value{x}, 0.9, 0.999, 1e-8, 0).Step(0.01)
+
+	assert.InDelta(t, 0.99, x.Data(), 1e-6)
+}
+
+func TestUnknownOptimizer(t *testing.T) {
+	_, err := New(Config{Name: "lion"}, nil)
+	assert.Error(t, err)
+}
+
+func TestClipGradNorm(t *testing.T) {
+	a := micrograd.NewValue(0)
+	b := micrograd.NewValue(0)
+	a.SetGrad(3)
+	b.SetGrad(4)
+	params := []*micrograd.Value{a, b}
+
+	norm := ClipGradNorm(params, 1.0)
+
+	assert.InDelta(t, 5.0, norm, 1e-9, "ClipGradNorm should return the norm before clipping")
+	assert.InDelta(t, 1.0, GradNorm(params), 1e-9, "Clipped norm should equal maxNorm")
+	assert.InDelta(t, 0.6, a.Grad(), 1e-9)
+	assert.InDelta(t, 0.8, b.Grad(), 1e-9)
+}
+
+func TestClipGradNormDisabled(t *testing.T) {
+	a := micrograd.NewValue(0)
+	a.SetGrad(10)
+
+	ClipGradNorm([]*micrograd.Value{a}, 0)
+
+	assert.Equal(t, 10.0, a.Grad(), "A zero max norm should leave gradients untouched")
+}
+
+func TestSchedules(t *testing.T) {
+	constant, err := NewSchedule(ScheduleConfig{Name: "constant", WarmupSteps: 4}, 1.0, 100)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, constant.LR(0), 1e-9, "Warmup should start at base/warmup")
+	assert.InDelta(t, 1.0, constant.LR(3), 1e-9, "Warmup should reach base on its last step")
+	assert.InDelta(t, 1.0, constant.LR(50), 1e-9)
+
+	cosine, err := NewSchedule(ScheduleConfig{Name: "cosine", MinLR: 0.1}, 1.0, 100)
+	require.NoError(t, err)
+	assert.InDelta(t, 1.0, cosine.LR(0), 1e-9)
+	assert.InDelta(t, 0.55, cosine.LR(50), 1e-9, "Cosine should be halfway at the midpoint")
+	assert.InDelta(t, 0.1, cosine.LR(100), 1e-9)
+	assert.InDelta(t, 0.1, cosine.LR(500), 1e-9, "Cosine should hold the minimum past the end")
+
+	linear, err := NewSchedule(ScheduleConfig{Name: "linear", WarmupSteps: 10}, 1.0, 110)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.5, linear.LR(60), 1e-9)
+
+	_, err = NewSchedule(ScheduleConfig{Name: "step"}, 1.0, 10)
+	assert.Error(t, err)
+
+	assert.False(t, math.IsNaN(Cosine{Base: 1, Warmup: 5, Total: 5}.LR(5)), "Degenerate schedules should not divide by zero")
+}
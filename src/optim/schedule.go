@@ -0,0 +1,95 @@
+package optim
+
+import (
+	"fmt"
+	"math"
+)
+
+// Schedule maps an optimizer step to a learning rate.
+type Schedule interface {
+	LR(step int) float64
+}
+
+type ScheduleConfig struct {
+	Name        string  `yaml:"name"`
+	WarmupSteps int     `yaml:"warmup_steps"`
+	MinLR       float64 `yaml:"min_lr"`
+}
+
+func NewSchedule(cfg ScheduleConfig, baseLR float64, totalSteps int) (Schedule, error) {
+	switch cfg.Name {
+	case "constant", "":
+		return Constant{Base: baseLR, Warmup: cfg.WarmupSteps}, nil
+	case "cosine":
+		return Cosine{Base: baseLR, Min: cfg.MinLR, Warmup: cfg.WarmupSteps, Total: totalSteps}, nil
+	case "linear":
+		return Linear{Base: baseLR, Min: cfg.MinLR, Warmup: cfg.WarmupSteps, Total: totalSteps}, nil
+	default:
+		return nil, fmt.Errorf("unknown schedule %q", cfg.Name)
+	}
+}
+
+// warmup ramps linearly from base/warmup at step 0 to base at step warmup-1.
+func warmup(base float64, warmup, step int) (float64, bool) {
+	if step < warmup {
+		return base * float64(step+1) / float64(warmup), true
+	}
+
+	return 0, false
+}
+
+type Constant struct {
+	Base   float64
+	Warmup int
+}
+
+func (s Constant) LR(step int) float64 {
+	if lr, ok := warmup(s.Base, s.Warmup, step); ok {
+		return lr
+	}
+
+	return s.Base
+}
+
+// Cosine decays from Base to Min over the steps after warmup.
+type Cosine struct {
+	Base   float64
+	Min    float64
+	Warmup int
+	Total  int
+}
+
+func (s Cosine) LR(step int) float64 {
+	if lr, ok := warmup(s.Base, s.Warmup, step); ok {
+		return lr
+	}
+
+	progress := decayProgress(step, s.Warmup, s.Total)
+	return s.Min + 0.5*(s.Base-s.Min)*(1+math.Cos(math.Pi*progress))
+}
+
+// Linear decays from Base to Min over the steps after warmup.
+type Linear struct {
+	Base   float64
+	Min    float64
+	Warmup int
+	Total  int
+}
+
+func (s Linear) LR(step int) float64 {
+	if lr, ok := warmup(s.Base, s.Warmup, step); ok {
+		return lr
+	}
+
+	progress := decayProgress(step, s.Warmup, s.Total)
+	return s.Base + (s.Min-s.Base)*progress
+}
+
+func decayProgress(step, warmup, total int) float64 {
+	span := total - warmup
+	if span <= 0 {
+		return 1
+	}
+
+	return math.Min(1, float64(step-warmup)/float64(span))
+}
@@ -0,0 +1,107 @@
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// Boundary shows the regions a 2D classifier assigns to each class, with
+// the labelled points drawn on top.
+type Boundary struct {
+	Title    string
+	Points   [][2]float64
+	Labels   []int
+	Classify func(x, y float64) int
+	// Cell is the side in pixels of the grid squares Classify is evaluated
+	// on. Smaller cells give smoother edges but call Classify more often.
+	Cell   int
+	Width  int
+	Height int
+}
+
+func (b *Boundary) Render() (image.Image, error) {
+	if len(b.Points) == 0 {
+		return nil, fmt.Errorf("boundary %q has no points to plot", b.Title)
+	}
+	if len(b.Labels) != len(b.Points) {
+		return nil, fmt.Errorf("boundary %q has %d points but %d labels", b.Title, len(b.Points), len(b.Labels))
+	}
+
+	width, height, cell := b.Width, b.Height, b.Cell
+	if width == 0 {
+		width = 600
+	}
+	if height == 0 {
+		height = 600
+	}
+	if cell == 0 {
+		cell = 4
+	}
+
+	xmin, xmax, ymin, ymax := b.bounds()
+	plotW := float64(width - marginLeft - marginRight)
+	plotH := float64(height - marginTop - marginBottom)
+	toPx := func(x, y float64) (float64, float64) {
+		return marginLeft + (x-xmin)/(xmax-xmin)*plotW,
+			marginTop + plotH - (y-ymin)/(ymax-ymin)*plotH
+	}
+
+	dc := gg.NewContext(width, height)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+
+	// Classify the centre of every cell and fill it with a pale class colour
+	for py := 0; py < int(plotH); py += cell {
+		for px := 0; px < int(plotW); px += cell {
+			x := xmin + (float64(px)+float64(cell)/2)/plotW*(xmax-xmin)
+			y := ymax - (float64(py)+float64(cell)/2)/plotH*(ymax-ymin)
+			col := palette[b.Classify(x, y)%len(palette)]
+			dc.SetColor(color.NRGBA{R: col.R, G: col.G, B: col.B, A: 80})
+			dc.DrawRectangle(float64(marginLeft+px), float64(marginTop+py),
+				math.Min(float64(cell), plotW-float64(px)), math.Min(float64(cell), plotH-float64(py)))
+			dc.Fill()
+		}
+	}
+
+	for i, p := range b.Points {
+		x, y := toPx(p[0], p[1])
+		dc.DrawCircle(x, y, 4)
+		dc.SetColor(palette[b.Labels[i]%len(palette)])
+		dc.FillPreserve()
+		dc.SetRGB(0, 0, 0)
+		dc.SetLineWidth(1)
+		dc.Stroke()
+	}
+
+	axes := &Chart{Title: b.Title, XLabel: "x", YLabel: "y"}
+	axes.drawAxes(dc, xmin, xmax, ymin, ymax, plotW, plotH)
+
+	return dc.Image(), nil
+}
+
+func (b *Boundary) SavePNG(path string) error {
+	img, err := b.Render()
+	if err != nil {
+		return err
+	}
+
+	return gg.SavePNG(path, img)
+}
+
+// bounds returns the range of the points padded by 10% on every side.
+func (b *Boundary) bounds() (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, p := range b.Points {
+		xmin, xmax = math.Min(xmin, p[0]), math.Max(xmax, p[0])
+		ymin, ymax = math.Min(ymin, p[1]), math.Max(ymax, p[1])
+	}
+
+	padX := math.Max((xmax-xmin)*0.1, 0.5)
+	padY := math.Max((ymax-ymin)*0.1, 0.5)
+	return xmin - padX, xmax + padX, ymin - padY, ymax + padY
+}
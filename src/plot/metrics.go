@@ -0,0 +1,162 @@
+package plot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Metrics maps a metric name to its values, indexed by training step.
+type Metrics map[string][]Point
+
+// ReadMetrics parses a training log in JSONL (one object per line) or CSV
+// (a header row after optional "#" comment lines) form. Every numeric field
+// other than "step" becomes a series plotted against the step; records
+// without a step use their line number.
+func ReadMetrics(r io.Reader) (Metrics, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	for err == nil && (first[0] == ' ' || first[0] == '\n' || first[0] == '\r' || first[0] == '\t') {
+		_, _ = br.ReadByte()
+		first, err = br.Peek(1)
+	}
+	if err == io.EOF {
+		return Metrics{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if first[0] == '{' {
+		return readJSONL(br)
+	}
+	return readCSV(br)
+}
+
+func ReadMetricsFile(path string) (Metrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m, err := ReadMetrics(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+func readJSONL(r io.Reader) (Metrics, error) {
+	m := Metrics{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		var record map[string]any
+		if err := json.Unmarshal(text, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		x := float64(line - 1)
+		if step, ok := record["step"].(float64); ok {
+			x = step
+		}
+		for name, v := range record {
+			if y, ok := v.(float64); ok && name != "step" {
+				m[name] = append(m[name], Point{X: x, Y: y})
+			}
+		}
+	}
+
+	return m, scanner.Err()
+}
+
+func readCSV(r io.Reader) (Metrics, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return Metrics{}, nil
+	}
+
+	header := rows[0]
+	stepCol := -1
+	for i, name := range header {
+		if strings.TrimSpace(name) == "step" {
+			stepCol = i
+		}
+	}
+
+	m := Metrics{}
+	for i, row := range rows[1:] {
+		x := float64(i)
+		if stepCol >= 0 {
+			if x, err = strconv.ParseFloat(strings.TrimSpace(row[stepCol]), 64); err != nil {
+				return nil, fmt.Errorf("row %d: bad step %q", i+2, row[stepCol])
+			}
+		}
+
+		for col, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if col == stepCol || col >= len(header) || cell == "" {
+				continue
+			}
+			// Non numeric columns such as timestamps are not plottable
+			if y, err := strconv.ParseFloat(cell, 64); err == nil {
+				name := strings.TrimSpace(header[col])
+				m[name] = append(m[name], Point{X: x, Y: y})
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// ChartSpec names the metrics drawn together on one chart.
+type ChartSpec struct {
+	File    string
+	Title   string
+	YLabel  string
+	Metrics []string
+}
+
+// TrainingCharts are the charts nanollm plot renders for a training run.
+var TrainingCharts = []ChartSpec{
+	{File: "loss.png", Title: "Loss", YLabel: "loss", Metrics: []string{"loss", "val_loss"}},
+	{File: "lr.png", Title: "Learning rate", YLabel: "learning rate", Metrics: []string{"lr"}},
+	{File: "grad_norm.png", Title: "Gradient norm", YLabel: "grad norm", Metrics: []string{"grad_norm"}},
+}
+
+// Chart builds the chart described by spec, or returns nil when none of its
+// metrics were logged.
+func (m Metrics) Chart(spec ChartSpec) *Chart {
+	c := &Chart{Title: spec.Title, XLabel: "step", YLabel: spec.YLabel}
+	for _, name := range spec.Metrics {
+		if points := m[name]; len(points) > 0 {
+			c.Series = append(c.Series, Series{Name: name, Points: points})
+		}
+	}
+	if len(c.Series) == 0 {
+		return nil
+	}
+
+	return c
+}
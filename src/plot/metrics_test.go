@@ -0,0 +1,59 @@
+package plot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMetricsJSONL(t *testing.T) {
+	log := `
+{"run": {"config_hash": "abc", "seed": 1}}
+{"step": 0, "loss": 3.5, "lr": 0.001, "grad_norm": 1.2}
+{"step": 1, "loss": 3.1, "lr": 0.002, "grad_norm": 1.0}
+{"step": 1, "val_loss": 3.3}
+
+{"step": 2, "loss": 2.9, "lr": 0.003, "grad_norm": 0.9, "note": "text is ignored"}
+`
+	m, err := ReadMetrics(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Point{{0, 3.5}, {1, 3.1}, {2, 2.9}}, m["loss"])
+	assert.Equal(t, []Point{{1, 3.3}}, m["val_loss"])
+	assert.Len(t, m["lr"], 3)
+	assert.NotContains(t, m, "note")
+	assert.NotContains(t, m, "step")
+	assert.NotContains(t, m, "run")
+}
+
+func TestReadMetricsCSV(t *testing.T) {
+	log := "# seed: 1\nstep,loss,val_loss,time\n0,3.5,,12:00\n10,3.0,3.2,12:01\n"
+
+	m, err := ReadMetrics(strings.NewReader(log))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Point{{0, 3.5}, {10, 3.0}}, m["loss"])
+	assert.Equal(t, []Point{{10, 3.2}}, m["val_loss"], "Empty cells should be skipped")
+	assert.NotContains(t, m, "time")
+}
+
+func TestReadMetricsErrors(t *testing.T) {
+	_, err := ReadMetrics(strings.NewReader("{\"step\": 0}\n{not json\n"))
+	assert.Error(t, err)
+
+	m, err := ReadMetrics(strings.NewReader("  \n"))
+	require.NoError(t, err)
+	assert.Empty(t, m)
+}
+
+func TestMetricsChart(t *testing.T) {
+	m := Metrics{"loss": {{0, 1}}, "val_loss": {{0, 2}}}
+
+	loss := m.Chart(TrainingCharts[0])
+	require.NotNil(t, loss)
+	assert.Len(t, loss.Series, 2)
+
+	assert.Nil(t, m.Chart(TrainingCharts[1]), "A chart with no logged metrics should be skipped")
+}
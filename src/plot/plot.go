@@ -0,0 +1,328 @@
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/fogleman/gg"
+)
+
+type Point struct {
+	X, Y float64
+}
+
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart is a line chart of one or more series sharing the same axes.
+type Chart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Series []Series
+	// LogY plots the y axis on a log10 scale. Points with y <= 0 are
+	// skipped since they have no position on it.
+	LogY bool
+	// Smoothing is the weight of an exponential moving average in [0, 1).
+	// When positive the raw curve is drawn faintly behind the smoothed one.
+	Smoothing float64
+	Width     int
+	Height    int
+}
+
+var palette = []color.RGBA{
+	{31, 119, 180, 255},
+	{255, 127, 14, 255},
+	{44, 160, 44, 255},
+	{214, 39, 40, 255},
+	{148, 103, 189, 255},
+	{140, 86, 75, 255},
+}
+
+const (
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 50
+	tickLength   = 5
+)
+
+// Render draws the chart. It fails when no series has a point that can be
+// plotted.
+func (c *Chart) Render() (image.Image, error) {
+	width, height := c.Width, c.Height
+	if width == 0 {
+		width = 800
+	}
+	if height == 0 {
+		height = 500
+	}
+
+	series := make([]Series, 0, len(c.Series))
+	for _, s := range c.Series {
+		s.Points = c.plottable(s.Points)
+		if len(s.Points) > 0 {
+			series = append(series, s)
+		}
+	}
+	if len(series) == 0 {
+		return nil, fmt.Errorf("chart %q has no points to plot", c.Title)
+	}
+
+	xmin, xmax, ymin, ymax := bounds(series, c.LogY)
+	plotW := float64(width - marginLeft - marginRight)
+	plotH := float64(height - marginTop - marginBottom)
+	toPx := func(p Point) (float64, float64) {
+		y := p.Y
+		if c.LogY {
+			y = math.Log10(y)
+		}
+		return marginLeft + (p.X-xmin)/(xmax-xmin)*plotW,
+			marginTop + plotH - (y-ymin)/(ymax-ymin)*plotH
+	}
+
+	dc := gg.NewContext(width, height)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+
+	c.drawAxes(dc, xmin, xmax, ymin, ymax, plotW, plotH)
+
+	// Keep lines inside the plot area
+	dc.DrawRectangle(marginLeft, marginTop, plotW, plotH)
+	dc.Clip()
+	for i, s := range series {
+		col := palette[i%len(palette)]
+		if c.Smoothing > 0 {
+			faint := color.NRGBA{R: col.R, G: col.G, B: col.B, A: 70}
+			drawLine(dc, s.Points, toPx, faint, 1)
+			drawLine(dc, Smooth(s.Points, c.Smoothing), toPx, col, 2)
+		} else {
+			drawLine(dc, s.Points, toPx, col, 2)
+		}
+	}
+	dc.ResetClip()
+
+	drawLegend(dc, series, width)
+
+	return dc.Image(), nil
+}
+
+func (c *Chart) EncodePNG(w io.Writer) error {
+	img, err := c.Render()
+	if err != nil {
+		return err
+	}
+
+	return gg.NewContextForImage(img).EncodePNG(w)
+}
+
+func (c *Chart) SavePNG(path string) error {
+	img, err := c.Render()
+	if err != nil {
+		return err
+	}
+
+	return gg.SavePNG(path, img)
+}
+
+func (c *Chart) plottable(points []Point) []Point {
+	out := make([]Point, 0, len(points))
+	for _, p := range points {
+		if math.IsNaN(p.Y) || math.IsInf(p.Y, 0) || (c.LogY && p.Y <= 0) {
+			continue
+		}
+		out = append(out, p)
+	}
+
+	return out
+}
+
+// bounds returns the data range, in log10 units for y when logY is set,
+// padded so flat series still get a visible range.
+func bounds(series []Series, logY bool) (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, s := range series {
+		for _, p := range s.Points {
+			y := p.Y
+			if logY {
+				y = math.Log10(y)
+			}
+			xmin, xmax = math.Min(xmin, p.X), math.Max(xmax, p.X)
+			ymin, ymax = math.Min(ymin, y), math.Max(ymax, y)
+		}
+	}
+
+	if xmax == xmin {
+		xmin, xmax = xmin-1, xmax+1
+	}
+	if ymax == ymin {
+		ymin, ymax = ymin-1, ymax+1
+	}
+	pad := (ymax - ymin) * 0.05
+
+	return xmin, xmax, ymin - pad, ymax + pad
+}
+
+func (c *Chart) drawAxes(dc *gg.Context, xmin, xmax, ymin, ymax, plotW, plotH float64) {
+	bottom := marginTop + plotH
+
+	dc.SetRGB(0, 0, 0)
+	dc.SetLineWidth(1)
+	dc.DrawLine(marginLeft, marginTop, marginLeft, bottom)
+	dc.DrawLine(marginLeft, bottom, marginLeft+plotW, bottom)
+	dc.Stroke()
+
+	for _, x := range Ticks(xmin, xmax, 8) {
+		px := marginLeft + (x-xmin)/(xmax-xmin)*plotW
+		dc.DrawLine(px, bottom, px, bottom+tickLength)
+		dc.Stroke()
+		dc.DrawStringAnchored(formatTick(x), px, bottom+tickLength+2, 0.5, 1)
+	}
+
+	yticks := Ticks(ymin, ymax, 6)
+	if c.LogY {
+		yticks = logTicks(ymin, ymax)
+	}
+	for _, y := range yticks {
+		py := marginTop + plotH - (y-ymin)/(ymax-ymin)*plotH
+
+		dc.SetRGBA(0, 0, 0, 0.1)
+		dc.DrawLine(marginLeft, py, marginLeft+plotW, py)
+		dc.Stroke()
+
+		dc.SetRGB(0, 0, 0)
+		dc.DrawLine(marginLeft-tickLength, py, marginLeft, py)
+		dc.Stroke()
+		label := y
+		if c.LogY {
+			label = math.Pow(10, y)
+		}
+		dc.DrawStringAnchored(formatTick(label), marginLeft-tickLength-3, py, 1, 0.5)
+	}
+
+	dc.DrawStringAnchored(c.Title, marginLeft+plotW/2, marginTop/2, 0.5, 0.5)
+	dc.DrawStringAnchored(c.XLabel, marginLeft+plotW/2, bottom+marginBottom-12, 0.5, 0.5)
+
+	dc.Push()
+	dc.RotateAbout(-math.Pi/2, 14, marginTop+plotH/2)
+	dc.DrawStringAnchored(c.YLabel, 14, marginTop+plotH/2, 0.5, 0.5)
+	dc.Pop()
+}
+
+func drawLine(dc *gg.Context, points []Point, toPx func(Point) (float64, float64), col color.Color, width float64) {
+	dc.SetColor(col)
+	dc.SetLineWidth(width)
+	for i, p := range points {
+		x, y := toPx(p)
+		if i == 0 {
+			dc.MoveTo(x, y)
+		} else {
+			dc.LineTo(x, y)
+		}
+	}
+	if len(points) == 1 {
+		x, y := toPx(points[0])
+		dc.DrawCircle(x, y, width+1)
+		dc.Fill()
+		return
+	}
+	dc.Stroke()
+}
+
+func drawLegend(dc *gg.Context, series []Series, width int) {
+	if len(series) < 2 {
+		return
+	}
+
+	x := float64(width - marginRight - 120)
+	y := float64(marginTop + 10)
+	for i, s := range series {
+		dc.SetColor(palette[i%len(palette)])
+		dc.SetLineWidth(2)
+		dc.DrawLine(x, y, x+20, y)
+		dc.Stroke()
+
+		dc.SetRGB(0, 0, 0)
+		dc.DrawStringAnchored(s.Name, x+26, y, 0, 0.5)
+		y += 16
+	}
+}
+
+// Smooth applies the debiased exponential moving average TensorBoard uses,
+// so the start of the curve is not pulled towards zero.
+func Smooth(points []Point, weight float64) []Point {
+	out := make([]Point, len(points))
+	var last, debias float64
+	for i, p := range points {
+		last = last*weight + (1-weight)*p.Y
+		debias = debias*weight + (1 - weight)
+		out[i] = Point{X: p.X, Y: last / debias}
+	}
+
+	return out
+}
+
+// Ticks returns round tick positions covering [min, max], aiming for about
+// n of them.
+func Ticks(min, max float64, n int) []float64 {
+	step := niceStep((max - min) / float64(n))
+	var ticks []float64
+	for k := math.Ceil(min / step); k*step <= max+step*1e-9; k++ {
+		// Round to 12 digits to drop noise such as 0.30000000000000004
+		t, _ := strconv.ParseFloat(strconv.FormatFloat(k*step, 'g', 12, 64), 64)
+		ticks = append(ticks, t)
+	}
+
+	return ticks
+}
+
+func niceStep(raw float64) float64 {
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	// Tolerate rounding so a raw step of 0.1000000001 still maps to 0.1
+	switch frac := raw / mag * (1 - 1e-9); {
+	case frac <= 1:
+		return mag
+	case frac <= 2:
+		return 2 * mag
+	case frac <= 5:
+		return 5 * mag
+	default:
+		return 10 * mag
+	}
+}
+
+// logTicks returns integer powers of ten within [min, max] in log10 units.
+// When the range spans less than a decade it places round values instead.
+func logTicks(min, max float64) []float64 {
+	var ticks []float64
+	for e := math.Ceil(min); e <= max; e++ {
+		ticks = append(ticks, e)
+	}
+	if len(ticks) >= 2 {
+		return ticks
+	}
+
+	ticks = ticks[:0]
+	for _, t := range Ticks(math.Pow(10, min), math.Pow(10, max), 5) {
+		if t > 0 {
+			ticks = append(ticks, math.Log10(t))
+		}
+	}
+
+	return ticks
+}
+
+func formatTick(v float64) string {
+	if v != 0 && (math.Abs(v) >= 1e5 || math.Abs(v) < 1e-3) {
+		return strconv.FormatFloat(v, 'e', 1, 64)
+	}
+
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
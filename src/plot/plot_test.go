@@ -0,0 +1,132 @@
+package plot
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func line(n int, f func(x float64) float64) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{X: float64(i), Y: f(float64(i))}
+	}
+	return points
+}
+
+// inkPixels counts pixels that are not plain white.
+func inkPixels(img image.Image) int {
+	count := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			if r != 0xffff || g != 0xffff || bl != 0xffff {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestRenderPNG(t *testing.T) {
+	c := &Chart{
+		Title:  "Loss",
+		XLabel: "step",
+		YLabel: "loss",
+		Series: []Series{
+			{Name: "loss", Points: line(100, func(x float64) float64 { return 4 / (1 + x) })},
+			{Name: "val_loss", Points: line(10, func(x float64) float64 { return 4/(1+10*x) + 0.1 })},
+		},
+		Smoothing: 0.6,
+		Width:     400,
+		Height:    300,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, c.EncodePNG(&buf))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 400, 300), img.Bounds())
+	assert.Greater(t, inkPixels(img), 1000, "Chart should draw axes, labels and lines")
+}
+
+func TestRenderLogScaleSkipsNonPositive(t *testing.T) {
+	c := &Chart{
+		Series: []Series{{Name: "lr", Points: []Point{{0, 0}, {1, 1e-4}, {2, 1e-2}, {3, -1}}}},
+		LogY:   true,
+	}
+
+	img, err := c.Render()
+	require.NoError(t, err)
+	assert.Greater(t, inkPixels(img), 0)
+
+	_, err = (&Chart{Series: []Series{{Points: []Point{{0, 0}}}}, LogY: true}).Render()
+	assert.Error(t, err, "A chart with nothing plottable should fail")
+}
+
+func TestRenderSinglePoint(t *testing.T) {
+	c := &Chart{Series: []Series{{Name: "val_loss", Points: []Point{{5, 2}}}}}
+
+	_, err := c.Render()
+	assert.NoError(t, err)
+}
+
+func TestSmooth(t *testing.T) {
+	points := []Point{{0, 1}, {1, 1}, {2, 1}}
+	for _, p := range Smooth(points, 0.9) {
+		assert.InDelta(t, 1.0, p.Y, 1e-12, "Debiased smoothing should keep a constant series constant")
+	}
+
+	step := Smooth([]Point{{0, 0}, {1, 10}}, 0.5)
+	assert.InDelta(t, 0.0, step[0].Y, 1e-12)
+	// (0.5*0.5*0 + 0.5*10) / (1 - 0.25)
+	assert.InDelta(t, 10.0/1.5, step[1].Y, 1e-12)
+}
+
+func TestTicks(t *testing.T) {
+	assert.Equal(t, []float64{0, 20, 40, 60, 80, 100}, Ticks(0, 100, 5))
+	assert.Equal(t, []float64{0.2, 0.3, 0.4}, Ticks(0.15, 0.45, 3))
+	assert.Equal(t, []float64{-4, -3, -2}, logTicks(-4.2, -1.5))
+}
+
+func TestFormatTick(t *testing.T) {
+	assert.Equal(t, "0.3", formatTick(0.30000000000000004))
+	assert.Equal(t, "1.0e-04", formatTick(1e-4))
+	assert.Equal(t, "0", formatTick(0))
+	assert.False(t, math.IsNaN(niceStep(3)))
+}
+
+func TestRenderBoundary(t *testing.T) {
+	b := &Boundary{
+		Title:  "halves",
+		Points: [][2]float64{{-1, 0}, {1, 0}, {-1, 1}, {1, 1}},
+		Labels: []int{0, 1, 0, 1},
+		Classify: func(x, y float64) int {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		},
+		Width:  200,
+		Height: 200,
+	}
+
+	img, err := b.Render()
+	require.NoError(t, err)
+
+	// Sample the shaded regions away from the points
+	left := img.At(marginLeft+5, marginTop+5)
+	right := img.At(200-marginRight-5, marginTop+5)
+	assert.NotEqual(t, left, right, "Each side of the boundary should be shaded by its class")
+
+	b.Labels = b.Labels[:1]
+	_, err = b.Render()
+	assert.Error(t, err, "Mismatched points and labels should be rejected")
+}
@@ -0,0 +1,196 @@
+package quant
+
+import (
+	"math"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+)
+
+// blockSize is the number of weights sharing a scale in the block formats,
+// as in ggml's Q8_0 and Q4_0. Rows are blocked independently; a row whose
+// length is not a multiple of blockSize ends in a short block.
+const blockSize = 32
+
+// blocks is the layout shared by the block formats: blocksPerRow float16
+// scales per row, and the quantized values in row order.
+type blocks struct {
+	rows, cols   int
+	blocksPerRow int
+	d            []dtype.F16
+}
+
+func newBlocks(rows, cols int) blocks {
+	perRow := (cols + blockSize - 1) / blockSize
+	return blocks{rows: rows, cols: cols, blocksPerRow: perRow, d: make([]dtype.F16, rows*perRow)}
+}
+
+func (b *blocks) Rows() int { return b.rows }
+func (b *blocks) Cols() int { return b.cols }
+
+// span returns the columns block j of a row covers.
+func (b *blocks) span(j int) (lo, hi int) {
+	return j * blockSize, min((j+1)*blockSize, b.cols)
+}
+
+// scale stores d as the scale of block i and returns the value actually
+// stored, so quantizing divides by the same float16 the kernels multiply
+// by.
+func (b *blocks) scale(i int, d float64) float64 {
+	b.d[i] = dtype.NewF16(d)
+	return b.d[i].Float64()
+}
+
+// q8_0 stores each weight as round(w/d) in int8, with d = absmax/127 per
+// block.
+type q8_0 struct {
+	blocks
+	q []int8
+}
+
+func quantizeQ8_0(w []float64, rows, cols int) *q8_0 {
+	m := &q8_0{blocks: newBlocks(rows, cols), q: make([]int8, rows*cols)}
+	for r := 0; r < rows; r++ {
+		for j := 0; j < m.blocksPerRow; j++ {
+			lo, hi := m.span(j)
+			block := w[r*cols+lo : r*cols+hi]
+			d := m.scale(r*m.blocksPerRow+j, absMax(block)/127)
+			if d == 0 {
+				continue
+			}
+			for k, v := range block {
+				m.q[r*cols+lo+k] = int8(max(-127, min(127, math.Round(v/d))))
+			}
+		}
+	}
+
+	return m
+}
+
+func (m *q8_0) Format() Format { return Q8_0 }
+func (m *q8_0) Size() int      { return 2*len(m.d) + len(m.q) }
+
+func (m *q8_0) MatMul(y, x []float64, n int) { matMul(m, y, x, n) }
+
+func (m *q8_0) Dequantize(dst []float64) {
+	for r := 0; r < m.rows; r++ {
+		for j := 0; j < m.blocksPerRow; j++ {
+			d := m.d[r*m.blocksPerRow+j].Float64()
+			lo, hi := m.span(j)
+			for c := lo; c < hi; c++ {
+				dst[r*m.cols+c] = d * float64(m.q[r*m.cols+c])
+			}
+		}
+	}
+}
+
+func (m *q8_0) dot(row int, x []float64) float64 {
+	q := m.q[row*m.cols : (row+1)*m.cols]
+	var sum float64
+	for j := 0; j < m.blocksPerRow; j++ {
+		lo, hi := m.span(j)
+		var block float64
+		for c := lo; c < hi; c++ {
+			block += float64(q[c]) * x[c]
+		}
+		sum += m.d[row*m.blocksPerRow+j].Float64() * block
+	}
+
+	return sum
+}
+
+// q4_0 stores each weight as a 4-bit q in [0, 15] meaning d·(q-8). d is
+// chosen so the weight with the largest magnitude maps to -8 exactly,
+// which spends the asymmetric extra level on it. Byte k of a block holds
+// weight k in its low nibble and weight k+16 in its high nibble.
+type q4_0 struct {
+	blocks
+	qs []uint8 // blockSize/2 bytes per block
+}
+
+func quantizeQ4_0(w []float64, rows, cols int) *q4_0 {
+	m := &q4_0{blocks: newBlocks(rows, cols)}
+	m.qs = make([]uint8, len(m.d)*blockSize/2)
+	for r := 0; r < rows; r++ {
+		for j := 0; j < m.blocksPerRow; j++ {
+			lo, hi := m.span(j)
+			block := w[r*cols+lo : r*cols+hi]
+
+			var extreme float64 // the weight with the largest magnitude, with its sign
+			for _, v := range block {
+				if math.Abs(v) > math.Abs(extreme) {
+					extreme = v
+				}
+			}
+			i := r*m.blocksPerRow + j
+			d := m.scale(i, extreme/-8)
+
+			qs := m.qs[i*blockSize/2 : (i+1)*blockSize/2]
+			for k := range qs {
+				qs[k] = nibble(block, k, d) | nibble(block, k+blockSize/2, d)<<4
+			}
+		}
+	}
+
+	return m
+}
+
+// nibble quantizes block[k], or a zero past the end of a short block.
+func nibble(block []float64, k int, d float64) uint8 {
+	if k >= len(block) || d == 0 {
+		return 8
+	}
+	return uint8(max(0, min(15, math.Round(block[k]/d)+8)))
+}
+
+// unpack returns q-8 for weight k of a block's bytes.
+func unpack(qs []uint8, k int) float64 {
+	q := qs[k%(blockSize/2)]
+	if k >= blockSize/2 {
+		q >>= 4
+	}
+	return float64(int(q&0xf) - 8)
+}
+
+func (m *q4_0) Format() Format { return Q4_0 }
+func (m *q4_0) Size() int      { return 2*len(m.d) + len(m.qs) }
+
+func (m *q4_0) MatMul(y, x []float64, n int) { matMul(m, y, x, n) }
+
+func (m *q4_0) Dequantize(dst []float64) {
+	for r := 0; r < m.rows; r++ {
+		for j := 0; j < m.blocksPerRow; j++ {
+			i := r*m.blocksPerRow + j
+			d := m.d[i].Float64()
+			qs := m.qs[i*blockSize/2 : (i+1)*blockSize/2]
+			lo, hi := m.span(j)
+			for c := lo; c < hi; c++ {
+				dst[r*m.cols+c] = d * unpack(qs, c-lo)
+			}
+		}
+	}
+}
+
+func (m *q4_0) dot(row int, x []float64) float64 {
+	const half = blockSize / 2
+	var sum float64
+	for j := 0; j < m.blocksPerRow; j++ {
+		i := row*m.blocksPerRow + j
+		qs := m.qs[i*half : (i+1)*half]
+		lo, hi := m.span(j)
+		xb := x[lo:hi]
+
+		var block float64
+		if len(xb) == blockSize {
+			for k, q := range qs {
+				block += float64(int(q&0xf)-8)*xb[k] + float64(int(q>>4)-8)*xb[k+half]
+			}
+		} else {
+			for k := range xb {
+				block += unpack(qs, k) * xb[k]
+			}
+		}
+		sum += m.d[i].Float64() * block
+	}
+
+	return sum
+}
@@ -0,0 +1,135 @@
+package quant
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Grimkey/nanollm/src/model"
+)
+
+// Model is a model.Model for inference with its linear layer weights
+// quantized. Embeddings and biases are small and stay float64.
+type Model struct {
+	Config    model.Config
+	Embedding []float64 // VocabSize×EmbedDim, row major
+	Layers    []Layer
+}
+
+// Layer is a fully connected layer: ReLU(W·x + B), or W·x + B for the
+// output layer.
+type Layer struct {
+	W    Matrix
+	B    []float64
+	ReLU bool
+}
+
+// QuantizeModel converts every layer of m's MLP to f. Each neuron is one
+// output channel.
+func QuantizeModel(m *model.Model, f Format) (*Model, error) {
+	q := &Model{Config: m.Config}
+	for _, row := range m.Embedding {
+		for _, v := range row {
+			q.Embedding = append(q.Embedding, v.Data())
+		}
+	}
+
+	for i, layer := range m.MLP.Layers {
+		rows, cols := len(layer.Neurons), len(layer.Neurons[0].W)
+		w := make([]float64, 0, rows*cols)
+		b := make([]float64, rows)
+		for r, n := range layer.Neurons {
+			for _, v := range n.W {
+				w = append(w, v.Data())
+			}
+			b[r] = n.B.Data()
+		}
+
+		mat, err := Quantize(f, w, rows, cols)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		q.Layers = append(q.Layers, Layer{W: mat, B: b, ReLU: layer.Neurons[0].Nonlin})
+	}
+
+	return q, nil
+}
+
+// WeightBytes is the storage taken by the quantized layer weights.
+func (m *Model) WeightBytes() int {
+	var n int
+	for _, l := range m.Layers {
+		n += l.W.Size()
+	}
+	return n
+}
+
+// Logits returns the next token logits for each context, VocabSize per
+// context, computing the whole batch one layer at a time.
+func (m *Model) Logits(contexts [][]int) []float64 {
+	n, dim := len(contexts), m.Config.EmbedDim
+	x := make([]float64, 0, n*m.Config.ContextSize*dim)
+	for _, ctx := range contexts {
+		for _, id := range ctx {
+			x = append(x, m.Embedding[id*dim:(id+1)*dim]...)
+		}
+	}
+
+	for _, l := range m.Layers {
+		rows := l.W.Rows()
+		y := make([]float64, n*rows)
+		l.W.MatMul(y, x, n)
+		for i := range y {
+			y[i] += l.B[i%rows]
+			if l.ReLU {
+				y[i] = max(y[i], 0)
+			}
+		}
+		x = y
+	}
+
+	return x
+}
+
+// perplexityBatch is how many windows Perplexity runs through Logits at a
+// time.
+const perplexityBatch = 64
+
+// Perplexity is exp of the mean cross entropy of predicting each token of
+// tokens from the ContextSize tokens before it.
+func (m *Model) Perplexity(tokens []int) (float64, error) {
+	size, vocab := m.Config.ContextSize, m.Config.VocabSize
+	windows := len(tokens) - size
+	if windows <= 0 {
+		return 0, fmt.Errorf("need more than %d tokens to measure perplexity, have %d", size, len(tokens))
+	}
+
+	var nll float64
+	for start := 0; start < windows; start += perplexityBatch {
+		var contexts [][]int
+		for i := start; i < min(start+perplexityBatch, windows); i++ {
+			contexts = append(contexts, tokens[i:i+size])
+		}
+
+		logits := m.Logits(contexts)
+		for i := range contexts {
+			nll += crossEntropy(logits[i*vocab:(i+1)*vocab], tokens[start+i+size])
+		}
+	}
+
+	return math.Exp(nll / float64(windows)), nil
+}
+
+// crossEntropy is -log softmax(logits)[target], shifted by the largest
+// logit so exp cannot overflow.
+func crossEntropy(logits []float64, target int) float64 {
+	hi := math.Inf(-1)
+	for _, l := range logits {
+		hi = max(hi, l)
+	}
+
+	var sum float64
+	for _, l := range logits {
+		sum += math.Exp(l - hi)
+	}
+	return math.Log(sum) + hi - logits[target]
+}
@@ -0,0 +1,83 @@
+package quant
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+	"github.com/Grimkey/nanollm/src/model"
+)
+
+func newModel(t *testing.T) *model.Model {
+	t.Helper()
+	// 4·8 inputs and 32 hidden units fill whole blocks
+	m, err := model.New(model.Config{VocabSize: 7, ContextSize: 4, EmbedDim: 8, Hidden: []int{32}}, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	return m
+}
+
+func randomTokens(n, vocab int) []int {
+	rng := rand.New(rand.NewSource(2))
+	tokens := make([]int, n)
+	for i := range tokens {
+		tokens[i] = rng.Intn(vocab)
+	}
+	return tokens
+}
+
+func TestFloat64ModelMatchesModel(t *testing.T) {
+	m := newModel(t)
+	q, err := QuantizeModel(m, Float64)
+	require.NoError(t, err)
+
+	contexts := [][]int{{0, 1, 2, 3}, {6, 5, 4, 3}}
+	logits := q.Logits(contexts)
+	require.Len(t, logits, 2*7)
+	for i, ctx := range contexts {
+		for j, want := range m.Forward(ctx) {
+			assert.InDelta(t, want.Data(), logits[i*7+j], 1e-12)
+		}
+	}
+}
+
+func TestPerplexity(t *testing.T) {
+	m := newModel(t)
+	tokens := randomTokens(150, 7)
+
+	// 146 windows spread over three Logits batches
+	var contexts [][]int
+	var targets []int
+	for i := 0; i+4 < len(tokens); i++ {
+		contexts = append(contexts, tokens[i:i+4])
+		targets = append(targets, tokens[i+4])
+	}
+	var loss float64
+	micrograd.NoGrad(func() { loss = m.Loss(contexts, targets).Data() })
+
+	float, err := QuantizeModel(m, Float64)
+	require.NoError(t, err)
+	ppl, err := float.Perplexity(tokens)
+	require.NoError(t, err)
+	assert.InDelta(t, math.Exp(loss), ppl, 1e-9)
+
+	// Quantized weights cost a little perplexity, 4 bits more than 8
+	deltas := map[Format]float64{}
+	for _, f := range []Format{Int8, Q8_0, Q4_0} {
+		q, err := QuantizeModel(m, f)
+		require.NoError(t, err)
+		qppl, err := q.Perplexity(tokens)
+		require.NoError(t, err)
+		deltas[f] = math.Abs(qppl-ppl) / ppl
+	}
+	assert.Less(t, deltas[Int8], 0.005)
+	assert.Less(t, deltas[Q8_0], 0.005)
+	assert.Less(t, deltas[Q4_0], 0.05)
+	assert.Greater(t, deltas[Q4_0], deltas[Q8_0])
+
+	_, err = float.Perplexity(tokens[:4])
+	assert.EqualError(t, err, "need more than 4 tokens to measure perplexity, have 4")
+}
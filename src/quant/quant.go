@@ -0,0 +1,185 @@
+package quant
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Grimkey/nanollm/src/gemm"
+)
+
+// Format is a storage format for a weight matrix.
+type Format uint8
+
+const (
+	// Float64 stores the weights unchanged, the baseline the others are
+	// compared against.
+	Float64 Format = iota
+	// Int8 stores each row as int8 with one float32 scale per row (per
+	// output channel).
+	Int8
+	// Q8_0 splits each row into blocks of 32 weights stored as int8 with
+	// one float16 scale per block.
+	Q8_0
+	// Q4_0 splits each row into blocks of 32 weights stored as 4-bit
+	// integers, two per byte, with one float16 scale per block.
+	Q4_0
+)
+
+var formatNames = [...]string{Float64: "float64", Int8: "int8", Q8_0: "q8_0", Q4_0: "q4_0"}
+
+// ParseFormat returns the Format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == name {
+			return Format(f), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown quantization format %q, want float64, int8, q8_0 or q4_0", name)
+}
+
+func (f Format) String() string {
+	if int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", f)
+}
+
+// Matrix is a rows×cols weight matrix held in some Format. Its kernels
+// dequantize on the fly, so the float weights are never materialized.
+type Matrix interface {
+	Rows() int
+	Cols() int
+	Format() Format
+	// Size is the number of bytes the weights and their scales take.
+	Size() int
+	// MatMul sets y to x·Wᵀ for n inputs: x is n×cols and y is n×rows, both
+	// row major. This is a linear layer applied to a batch.
+	MatMul(y, x []float64, n int)
+	// Dequantize writes the weights row major into dst, which must hold
+	// rows×cols values.
+	Dequantize(dst []float64)
+}
+
+// Quantize converts the row major rows×cols matrix w to f. Each row is one
+// output channel and is quantized on its own.
+func Quantize(f Format, w []float64, rows, cols int) (Matrix, error) {
+	if rows <= 0 || cols <= 0 || len(w) != rows*cols {
+		return nil, fmt.Errorf("quantize %dx%d matrix from %d values", rows, cols, len(w))
+	}
+
+	switch f {
+	case Float64:
+		return &dense{rows: rows, cols: cols, w: append([]float64(nil), w...)}, nil
+	case Int8:
+		return quantizeInt8(w, rows, cols), nil
+	case Q8_0:
+		return quantizeQ8_0(w, rows, cols), nil
+	case Q4_0:
+		return quantizeQ4_0(w, rows, cols), nil
+	}
+
+	return nil, fmt.Errorf("unknown quantization format %v", f)
+}
+
+// rowDotter is the per-row kernel a format provides; matMul runs it over
+// every input and row.
+type rowDotter interface {
+	Matrix
+	dot(row int, x []float64) float64
+}
+
+func matMul(m rowDotter, y, x []float64, n int) {
+	checkMatMul(m, y, x, n)
+	rows, cols := m.Rows(), m.Cols()
+
+	for i := 0; i < n; i++ {
+		xi, yi := x[i*cols:(i+1)*cols], y[i*rows:(i+1)*rows]
+		for r := range yi {
+			yi[r] = m.dot(r, xi)
+		}
+	}
+}
+
+// checkMatMul panics unless x and y hold n inputs and outputs of m.
+func checkMatMul(m Matrix, y, x []float64, n int) {
+	rows, cols := m.Rows(), m.Cols()
+	if len(x) < n*cols || len(y) < n*rows {
+		panic(fmt.Sprintf("quant: %d inputs to a %dx%d matrix need len(x) >= %d and len(y) >= %d, got %d and %d",
+			n, rows, cols, n*cols, n*rows, len(x), len(y)))
+	}
+}
+
+// absMax is the largest magnitude in w.
+func absMax(w []float64) float64 {
+	var m float64
+	for _, v := range w {
+		m = max(m, math.Abs(v))
+	}
+	return m
+}
+
+type dense struct {
+	rows, cols int
+	w          []float64
+}
+
+func (m *dense) Rows() int      { return m.rows }
+func (m *dense) Cols() int      { return m.cols }
+func (m *dense) Format() Format { return Float64 }
+func (m *dense) Size() int      { return 8 * len(m.w) }
+
+func (m *dense) Dequantize(dst []float64) { copy(dst, m.w) }
+
+// MatMul needs no dequantizing, so it is a single gemm.Gemm over the
+// batch rather than a dot per row.
+func (m *dense) MatMul(y, x []float64, n int) {
+	checkMatMul(m, y, x, n)
+	gemm.Gemm(gemm.NoTrans, gemm.Trans, n, m.rows, m.cols, x[:n*m.cols], m.w, 0, y[:n*m.rows])
+}
+
+// int8Matrix is per-channel symmetric int8: row r is scale[r]·q[r].
+type int8Matrix struct {
+	rows, cols int
+	q          []int8
+	scale      []float32
+}
+
+func quantizeInt8(w []float64, rows, cols int) *int8Matrix {
+	m := &int8Matrix{rows: rows, cols: cols, q: make([]int8, rows*cols), scale: make([]float32, rows)}
+	for r := 0; r < rows; r++ {
+		row := w[r*cols : (r+1)*cols]
+		scale := float32(absMax(row) / 127)
+		m.scale[r] = scale
+		if scale == 0 {
+			continue
+		}
+		for c, v := range row {
+			m.q[r*cols+c] = int8(max(-127, min(127, math.Round(v/float64(scale)))))
+		}
+	}
+
+	return m
+}
+
+func (m *int8Matrix) Rows() int      { return m.rows }
+func (m *int8Matrix) Cols() int      { return m.cols }
+func (m *int8Matrix) Format() Format { return Int8 }
+func (m *int8Matrix) Size() int      { return len(m.q) + 4*len(m.scale) }
+
+func (m *int8Matrix) MatMul(y, x []float64, n int) { matMul(m, y, x, n) }
+
+func (m *int8Matrix) Dequantize(dst []float64) {
+	for i, q := range m.q {
+		dst[i] = float64(m.scale[i/m.cols]) * float64(q)
+	}
+}
+
+func (m *int8Matrix) dot(row int, x []float64) float64 {
+	var sum float64
+	for c, q := range m.q[row*m.cols : (row+1)*m.cols] {
+		sum += float64(q) * x[c]
+	}
+	// The scale is common to the row, so it is applied once
+	return float64(m.scale[row]) * sum
+}
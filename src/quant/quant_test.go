@@ -0,0 +1,163 @@
+package quant
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var formats = []Format{Float64, Int8, Q8_0, Q4_0}
+
+func randomMatrix(rng *rand.Rand, rows, cols int) []float64 {
+	w := make([]float64, rows*cols)
+	for i := range w {
+		w[i] = rng.NormFloat64()
+	}
+	return w
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range formats {
+		got, err := ParseFormat(f.String())
+		require.NoError(t, err)
+		assert.Equal(t, f, got)
+	}
+
+	_, err := ParseFormat("q2_k")
+	assert.EqualError(t, err, `unknown quantization format "q2_k", want float64, int8, q8_0 or q4_0`)
+}
+
+func TestQuantizeRejectsBadShapes(t *testing.T) {
+	_, err := Quantize(Int8, make([]float64, 5), 2, 3)
+	assert.EqualError(t, err, "quantize 2x3 matrix from 5 values")
+	_, err = Quantize(Q4_0, nil, 0, 3)
+	assert.Error(t, err)
+}
+
+// The kernels dequantize on the fly; they must agree with dequantizing
+// first and multiplying in float64.
+func TestMatMulMatchesDequantized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// 70 columns leave a short block at the end of every row
+	rows, cols, n := 5, 70, 3
+	w := randomMatrix(rng, rows, cols)
+	x := randomMatrix(rng, n, cols)
+
+	for _, f := range formats {
+		m, err := Quantize(f, w, rows, cols)
+		require.NoError(t, err)
+		assert.Equal(t, f, m.Format())
+
+		deq := make([]float64, rows*cols)
+		m.Dequantize(deq)
+		want := make([]float64, n*rows)
+		for i := 0; i < n; i++ {
+			for r := 0; r < rows; r++ {
+				for c := 0; c < cols; c++ {
+					want[i*rows+r] += deq[r*cols+c] * x[i*cols+c]
+				}
+			}
+		}
+
+		got := make([]float64, n*rows)
+		m.MatMul(got, x, n)
+		assert.InDeltaSlice(t, want, got, 1e-12, "%v", f)
+	}
+}
+
+func TestQuantizationError(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	rows, cols := 16, 96
+	w := randomMatrix(rng, rows, cols)
+
+	rms := map[Format]float64{}
+	for _, f := range formats {
+		m, err := Quantize(f, w, rows, cols)
+		require.NoError(t, err)
+		deq := make([]float64, rows*cols)
+		m.Dequantize(deq)
+
+		var sum float64
+		for i := range w {
+			sum += (deq[i] - w[i]) * (deq[i] - w[i])
+		}
+		rms[f] = math.Sqrt(sum / float64(len(w)))
+	}
+
+	assert.Zero(t, rms[Float64])
+	// A unit normal row spans about ±3, so 8-bit steps are near 0.02 and
+	// 4-bit steps near 0.4; the rounding error is a fraction of a step
+	assert.Less(t, rms[Int8], 0.01)
+	assert.Less(t, rms[Q8_0], 0.01)
+	assert.Less(t, rms[Q4_0], 0.15)
+	assert.Greater(t, rms[Q4_0], 4*rms[Q8_0])
+}
+
+func TestQ4_0Layout(t *testing.T) {
+	// The largest magnitude is -8, so d is 1 and every integer in [-8, 7]
+	// is exact
+	w := make([]float64, 32)
+	for i := range w {
+		w[i] = float64(i%16 - 8)
+	}
+	m, err := Quantize(Q4_0, w, 1, 32)
+	require.NoError(t, err)
+
+	q := m.(*q4_0)
+	assert.Equal(t, 1.0, q.d[0].Float64())
+	// Weight k sits in the low nibble of byte k and weight k+16 in the high
+	assert.Equal(t, uint8(0x00), q.qs[0])
+	assert.Equal(t, uint8(0xff), q.qs[15])
+
+	deq := make([]float64, 32)
+	m.Dequantize(deq)
+	assert.Equal(t, w, deq)
+}
+
+func TestZeroRows(t *testing.T) {
+	w := []float64{0, 0, 0, 1, -2, 3}
+	for _, f := range formats {
+		m, err := Quantize(f, w, 2, 3)
+		require.NoError(t, err)
+		y := make([]float64, 2)
+		m.MatMul(y, []float64{1, 1, 1}, 1)
+		assert.Zero(t, y[0], "%v", f)
+		assert.InDelta(t, 2, y[1], 0.3, "%v", f)
+	}
+}
+
+func TestSize(t *testing.T) {
+	w := make([]float64, 64*64)
+	sizes := map[Format]int{}
+	for _, f := range formats {
+		m, err := Quantize(f, w, 64, 64)
+		require.NoError(t, err)
+		sizes[f] = m.Size()
+	}
+
+	assert.Equal(t, map[Format]int{
+		Float64: 8 * 4096,
+		Int8:    4096 + 4*64,    // a float32 scale per row
+		Q8_0:    128 * (2 + 32), // 128 blocks with a float16 scale each
+		Q4_0:    128 * (2 + 16),
+	}, sizes)
+}
+
+func BenchmarkMatMul(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	rows, cols, n := 768, 768, 8
+	w, x := randomMatrix(rng, rows, cols), randomMatrix(rng, n, cols)
+	y := make([]float64, n*rows)
+	for _, f := range formats {
+		m, err := Quantize(f, w, rows, cols)
+		require.NoError(b, err)
+		b.Run(f.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.MatMul(y, x, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package sample
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+type Options struct {
+	MaxTokens int
+	// Temperature divides the logits before sampling; 0 picks the most
+	// likely token every time.
+	Temperature float64
+	// TopK keeps only the K most likely tokens, 0 keeps all of them.
+	TopK int
+	// TopP keeps the smallest set of tokens whose probability mass reaches
+	// P, 0 or 1 keeps all of them.
+	TopP float64
+}
+
+func DefaultOptions() Options {
+	return Options{MaxTokens: 200, Temperature: 1.0}
+}
+
+func (o Options) Validate() error {
+	if o.MaxTokens < 0 {
+		return fmt.Errorf("max tokens must not be negative, got %d", o.MaxTokens)
+	}
+	if o.Temperature < 0 {
+		return fmt.Errorf("temperature must not be negative, got %g", o.Temperature)
+	}
+	if o.TopK < 0 {
+		return fmt.Errorf("top-k must not be negative, got %d", o.TopK)
+	}
+	if o.TopP < 0 || o.TopP > 1 {
+		return fmt.Errorf("top-p must be in [0, 1], got %g", o.TopP)
+	}
+
+	return nil
+}
+
+// Next picks a token id from logits according to opts.
+func Next(logits []float64, opts Options, rng *rand.Rand) int {
+	if opts.Temperature == 0 {
+		best := 0
+		for i, l := range logits {
+			if l > logits[best] {
+				best = i
+			}
+		}
+		return best
+	}
+
+	ids := make([]int, len(logits))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.SliceStable(ids, func(a, b int) bool { return logits[ids[a]] > logits[ids[b]] })
+
+	if opts.TopK > 0 && opts.TopK < len(ids) {
+		ids = ids[:opts.TopK]
+	}
+
+	// Softmax over the surviving tokens, shifted by the largest logit
+	probs := make([]float64, len(ids))
+	var total float64
+	for i, id := range ids {
+		probs[i] = math.Exp((logits[id] - logits[ids[0]]) / opts.Temperature)
+		total += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= total
+	}
+
+	if opts.TopP > 0 && opts.TopP < 1 {
+		var mass float64
+		for i, p := range probs {
+			mass += p
+			if mass >= opts.TopP {
+				ids, probs = ids[:i+1], probs[:i+1]
+				total = mass
+				break
+			}
+		}
+	} else {
+		total = 1
+	}
+
+	r := rng.Float64() * total
+	for i, p := range probs {
+		r -= p
+		if r < 0 {
+			return ids[i]
+		}
+	}
+
+	return ids[len(ids)-1]
+}
+
+// Generate extends prompt by up to opts.MaxTokens tokens, calling emit with
+// each token as soon as it is chosen. It stops early when ctx is cancelled
+// or emit returns an error, and returns the generated tokens.
+func Generate(ctx context.Context, m *model.Model, prompt []int, opts Options, rng *rand.Rand, emit func(id int) error) ([]int, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	ids := append([]int{}, prompt...)
+	generated := make([]int, 0, opts.MaxTokens)
+	logits := make([]float64, m.Config.VocabSize)
+
+	for len(generated) < opts.MaxTokens {
+		if err := ctx.Err(); err != nil {
+			return generated, err
+		}
+
+		copy(logits, m.Logits([][]int{m.Context(ids)}))
+
+		id := Next(logits, opts, rng)
+		ids = append(ids, id)
+		generated = append(generated, id)
+
+		if emit != nil {
+			if err := emit(id); err != nil {
+				return generated, err
+			}
+		}
+	}
+
+	return generated, nil
+}
+
+// Stream turns a sequence of token ids into text increments. Tokenizers like
+// Byte can split a character across tokens, so a trailing partial character
+// is held back until the rest of it arrives. Each token is decoded on its
+// own, which relies on the tokenizer decoding a sequence as the
+// concatenation of its tokens, as Char and Byte do.
+type Stream struct {
+	tok     tokenizer.Tokenizer
+	text    []byte // everything decoded so far
+	emitted int    // bytes of text already returned by Push
+}
+
+func NewStream(tok tokenizer.Tokenizer) *Stream {
+	return &Stream{tok: tok}
+}
+
+// Push adds a token and returns the text that became printable.
+func (s *Stream) Push(id int) string {
+	s.text = append(s.text, s.tok.Decode([]int{id})...)
+	pending := s.text[s.emitted:]
+
+	cut := len(pending)
+	for i := len(pending) - 1; i >= 0 && i >= len(pending)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(pending[i]) {
+			if !utf8.FullRune(pending[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+
+	s.emitted += cut
+	return strings.ToValidUTF8(string(pending[:cut]), "\uFFFD")
+}
+
+// Text returns everything decoded so far, including any incomplete tail.
+func (s *Stream) Text() string {
+	return strings.ToValidUTF8(string(s.text), "\uFFFD")
+}
@@ -0,0 +1,156 @@
+package sample
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+func TestNextGreedy(t *testing.T) {
+	logits := []float64{0.1, 2.0, -1.0, 1.9}
+
+	assert.Equal(t, 1, Next(logits, Options{Temperature: 0}, rand.New(rand.NewSource(1))))
+}
+
+func TestNextTopK(t *testing.T) {
+	logits := []float64{1.0, 1.1, 5.0, 5.1}
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		id := Next(logits, Options{Temperature: 10, TopK: 2}, rng)
+		assert.Contains(t, []int{2, 3}, id, "Top-k should only sample the two best tokens")
+	}
+}
+
+func TestNextTopP(t *testing.T) {
+	// Probabilities are roughly 0.84, 0.11, 0.04, 0.01
+	logits := []float64{4, 2, 1, 0}
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		id := Next(logits, Options{Temperature: 1, TopP: 0.9}, rng)
+		assert.Contains(t, []int{0, 1}, id, "Top-p 0.9 should only keep the first two tokens")
+	}
+}
+
+func TestNextFollowsDistribution(t *testing.T) {
+	logits := []float64{0, 0}
+	rng := rand.New(rand.NewSource(1))
+
+	counts := make([]int, 2)
+	for i := 0; i < 2000; i++ {
+		counts[Next(logits, Options{Temperature: 1}, rng)]++
+	}
+
+	assert.InDelta(t, 1000, counts[0], 100, "Equal logits should be sampled about equally")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultOptions().Validate())
+	assert.Error(t, Options{Temperature: -1}.Validate())
+	assert.Error(t, Options{TopP: 1.5}.Validate())
+	assert.Error(t, Options{TopK: -1}.Validate())
+}
+
+func newModel(t *testing.T) *model.Model {
+	t.Helper()
+	m, err := model.New(model.Config{VocabSize: 4, ContextSize: 2, EmbedDim: 2, Hidden: []int{4}}, rand.New(rand.NewSource(3)))
+	require.NoError(t, err)
+	return m
+}
+
+func TestGenerateStreamsEveryToken(t *testing.T) {
+	m := newModel(t)
+
+	var streamed []int
+	out, err := Generate(context.Background(), m, []int{1}, Options{MaxTokens: 5, Temperature: 1}, rand.New(rand.NewSource(1)), func(id int) error {
+		streamed = append(streamed, id)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, out, 5)
+	assert.Equal(t, out, streamed)
+}
+
+func TestGenerateIsDeterministicForASeed(t *testing.T) {
+	m := newModel(t)
+	opts := Options{MaxTokens: 10, Temperature: 1}
+
+	a, err := Generate(context.Background(), m, nil, opts, rand.New(rand.NewSource(9)), nil)
+	require.NoError(t, err)
+	b, err := Generate(context.Background(), m, nil, opts, rand.New(rand.NewSource(9)), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+}
+
+func TestGenerateStopsOnEmitError(t *testing.T) {
+	m := newModel(t)
+	stop := errors.New("stop")
+
+	out, err := Generate(context.Background(), m, nil, Options{MaxTokens: 10, Temperature: 1}, rand.New(rand.NewSource(1)), func(int) error {
+		return stop
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Len(t, out, 1)
+}
+
+func TestGenerateStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	out, err := Generate(ctx, newModel(t), nil, Options{MaxTokens: 10}, rand.New(rand.NewSource(1)), nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, out)
+}
+
+func TestStreamHoldsBackPartialCharacters(t *testing.T) {
+	s := NewStream(tokenizer.Byte{})
+	ids := tokenizer.Byte{}.Encode("aé") // 'a', 0xC3, 0xA9
+
+	assert.Equal(t, "a", s.Push(ids[0]))
+	assert.Equal(t, "", s.Push(ids[1]), "The first byte of é should be held back")
+	assert.Equal(t, "é", s.Push(ids[2]))
+	assert.Equal(t, "aé", s.Text())
+}
+
+func TestStreamDecodesOnlyNewTokens(t *testing.T) {
+	tok := &countingTokenizer{Tokenizer: tokenizer.Byte{}}
+	s := NewStream(tok)
+	for _, id := range (tokenizer.Byte{}).Encode(strings.Repeat("ab", 500)) {
+		s.Push(id)
+	}
+
+	assert.Equal(t, 1000, tok.decoded, "Every token should be decoded once")
+	assert.Equal(t, strings.Repeat("ab", 500), s.Text())
+}
+
+// countingTokenizer counts the ids passed to Decode.
+type countingTokenizer struct {
+	tokenizer.Tokenizer
+	decoded int
+}
+
+func (c *countingTokenizer) Decode(ids []int) string {
+	c.decoded += len(ids)
+	return c.Tokenizer.Decode(ids)
+}
+
+func TestStreamReplacesInvalidBytes(t *testing.T) {
+	s := NewStream(tokenizer.Byte{})
+
+	// A lone continuation byte can never become valid
+	assert.Equal(t, "�", s.Push(0xA9))
+	assert.Equal(t, "b", s.Push('b'))
+}
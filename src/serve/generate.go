@@ -0,0 +1,91 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Grimkey/nanollm/src/sample"
+)
+
+const (
+	finishStop   = "stop"
+	finishLength = "length"
+)
+
+type completion struct {
+	text   string
+	tokens int
+	finish string
+}
+
+var errStopSequence = errors.New("stop sequence reached")
+
+// complete generates one choice. onText, when set, receives text as soon as
+// it can no longer turn out to be the start of a stop sequence; the
+// concatenation of everything passed to it equals the returned text.
+func (s *Server) complete(ctx context.Context, prompt []int, p params, rng *rand.Rand, onText func(string) error) (completion, error) {
+	holdback := 0
+	for _, seq := range p.stop {
+		holdback = max(holdback, len(seq)-1)
+	}
+
+	var res completion
+	sent := 0
+	flush := func(end int) error {
+		if end <= sent {
+			return nil
+		}
+		chunk := res.text[sent:end]
+		sent = end
+		if onText == nil {
+			return nil
+		}
+		return onText(chunk)
+	}
+
+	stream := sample.NewStream(s.tok)
+	_, err := sample.Generate(ctx, s.model, prompt, p.opts, rng, func(id int) error {
+		res.tokens++
+		res.text += stream.Push(id)
+
+		// Earlier text was already checked, so a match has to end in the
+		// part that has not been sent yet.
+		if i := firstStop(res.text[sent:], p.stop); i >= 0 {
+			res.text = res.text[:sent+i]
+			res.finish = finishStop
+			if err := flush(len(res.text)); err != nil {
+				return err
+			}
+			return errStopSequence
+		}
+
+		end := len(res.text) - holdback
+		for end > sent && end < len(res.text) && !utf8.RuneStart(res.text[end]) {
+			end--
+		}
+		return flush(end)
+	})
+	if errors.Is(err, errStopSequence) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+
+	res.finish = finishLength
+	return res, flush(len(res.text))
+}
+
+func firstStop(text string, stop []string) int {
+	first := -1
+	for _, seq := range stop {
+		if i := strings.Index(text, seq); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,256 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// sse writes server-sent events in the framing OpenAI clients expect.
+type sse struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+func startSSE(w http.ResponseWriter) *sse {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	f, _ := w.(http.Flusher)
+	return &sse{w: w, f: f}
+}
+
+func (e *sse) send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return e.raw(string(data))
+}
+
+func (e *sse) raw(data string) error {
+	if _, err := fmt.Fprintf(e.w, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	if e.f != nil {
+		e.f.Flush()
+	}
+
+	return nil
+}
+
+// fail reports an error after the stream has started, when the status code
+// can no longer change.
+func (e *sse) fail(err error) {
+	_ = e.send(ErrorResponse{Error: APIError{Message: err.Error(), Type: "server_error"}})
+}
+
+func (s *Server) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	var req CompletionRequest
+	if err := decode(r, w, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.checkModel(req.Model); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	prompts := []string(req.Prompt)
+	if len(prompts) == 0 {
+		prompts = []string{""}
+	}
+
+	p, err := s.params(len(prompts), req.MaxTokens, req.Temperature, req.TopP, req.TopK, req.N, req.Stop, req.Seed)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := s.acquire(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.release()
+
+	rng := s.rng(p)
+	base := CompletionResponse{ID: newID("cmpl-"), Object: "text_completion", Created: s.now().Unix(), Model: s.cfg.ModelID}
+
+	var events *sse
+	if req.Stream {
+		events = startSSE(w)
+	}
+
+	resp := base
+	usage := Usage{}
+	for i, prompt := range prompts {
+		ids := s.tok.Encode(prompt)
+		usage.PromptTokens += len(ids)
+
+		for j := 0; j < p.n; j++ {
+			index := i*p.n + j
+
+			var onText func(string) error
+			if events != nil {
+				onText = func(text string) error {
+					chunk := base
+					chunk.Choices = []CompletionChoice{{Text: text, Index: index}}
+					return events.send(chunk)
+				}
+			}
+
+			res, err := s.complete(r.Context(), ids, p, rng, onText)
+			if err != nil {
+				if events != nil {
+					events.fail(err)
+				} else {
+					writeError(w, err)
+				}
+				return
+			}
+			usage.CompletionTokens += res.tokens
+
+			finish := res.finish
+			if events != nil {
+				chunk := base
+				chunk.Choices = []CompletionChoice{{Index: index, FinishReason: &finish}}
+				if err := events.send(chunk); err != nil {
+					return
+				}
+				continue
+			}
+			resp.Choices = append(resp.Choices, CompletionChoice{Text: res.text, Index: index, FinishReason: &finish})
+		}
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if events != nil {
+		_ = events.raw("[DONE]")
+		return
+	}
+
+	resp.Usage = &usage
+	writeJSON(w, http.StatusOK, resp)
+}
+
+var chatRoles = map[string]bool{"system": true, "developer": true, "user": true, "assistant": true}
+
+// chatPrompt flattens a conversation into the plain text the model was
+// trained on, ending with the assistant's turn.
+func chatPrompt(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role)
+		b.WriteString(": ")
+		b.WriteString(m.Content)
+		b.WriteString("\n")
+	}
+	b.WriteString("assistant:")
+
+	return b.String()
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var req ChatCompletionRequest
+	if err := decode(r, w, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.checkModel(req.Model); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, invalid("messages", "messages must not be empty"))
+		return
+	}
+	for i, m := range req.Messages {
+		if !chatRoles[m.Role] {
+			writeError(w, invalid(fmt.Sprintf("messages[%d].role", i), "unsupported role %q", m.Role))
+			return
+		}
+	}
+
+	p, err := s.params(1, req.MaxTokens, req.Temperature, req.TopP, req.TopK, req.N, req.Stop, req.Seed)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// Keep the model from writing the user's next turn
+	p.stop = append(p.stop, "\nuser:")
+
+	if err := s.acquire(r.Context()); err != nil {
+		writeError(w, err)
+		return
+	}
+	defer s.release()
+
+	rng := s.rng(p)
+	ids := s.tok.Encode(chatPrompt(req.Messages))
+	base := ChatCompletionResponse{ID: newID("chatcmpl-"), Object: "chat.completion", Created: s.now().Unix(), Model: s.cfg.ModelID}
+
+	var events *sse
+	if req.Stream {
+		events = startSSE(w)
+		base.Object = "chat.completion.chunk"
+	}
+
+	resp := base
+	usage := Usage{PromptTokens: len(ids)}
+	for index := 0; index < p.n; index++ {
+		var onText func(string) error
+		if events != nil {
+			// The first chunk of each choice announces the role
+			chunk := base
+			chunk.Choices = []ChatChoice{{Index: index, Delta: &ChatDelta{Role: "assistant"}}}
+			if err := events.send(chunk); err != nil {
+				return
+			}
+
+			onText = func(text string) error {
+				chunk := base
+				chunk.Choices = []ChatChoice{{Index: index, Delta: &ChatDelta{Content: text}}}
+				return events.send(chunk)
+			}
+		}
+
+		res, err := s.complete(r.Context(), ids, p, rng, onText)
+		if err != nil {
+			if events != nil {
+				events.fail(err)
+			} else {
+				writeError(w, err)
+			}
+			return
+		}
+		usage.CompletionTokens += res.tokens
+
+		finish := res.finish
+		if events != nil {
+			chunk := base
+			chunk.Choices = []ChatChoice{{Index: index, Delta: &ChatDelta{}, FinishReason: &finish}}
+			if err := events.send(chunk); err != nil {
+				return
+			}
+			continue
+		}
+		resp.Choices = append(resp.Choices, ChatChoice{
+			Index:        index,
+			Message:      &ChatMessage{Role: "assistant", Content: res.text},
+			FinishReason: &finish,
+		})
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if events != nil {
+		_ = events.raw("[DONE]")
+		return
+	}
+
+	resp.Usage = &usage
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,283 @@
+package serve
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/sample"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+type Config struct {
+	// ModelID is the name clients pass in the model field.
+	ModelID string
+	// MaxConcurrent bounds the number of generations running at once.
+	MaxConcurrent int
+	// MaxQueue bounds the number of requests waiting for a free slot.
+	// Requests beyond it are rejected with 429.
+	MaxQueue int
+	// MaxTokens is the largest max_tokens a request may ask for.
+	MaxTokens int
+	// DefaultMaxTokens is used when a request does not set max_tokens.
+	DefaultMaxTokens int
+	// MaxN is the largest number of choices a request may ask for.
+	MaxN int
+	// MaxPrompts is the most prompts one completion request may send. Every
+	// prompt gets n choices, all generated in the request's single slot.
+	MaxPrompts int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ModelID:          "nanollm",
+		MaxConcurrent:    4,
+		MaxQueue:         16,
+		MaxTokens:        1024,
+		DefaultMaxTokens: 16,
+		MaxN:             8,
+		MaxPrompts:       8,
+	}
+}
+
+const maxBodyBytes = 1 << 20
+
+// Server exposes a model through an OpenAI compatible HTTP API. The model
+// is only read during generation, so requests share it without locking.
+type Server struct {
+	cfg     Config
+	model   *model.Model
+	tok     tokenizer.Tokenizer
+	slots   chan struct{}
+	waiting atomic.Int64
+	created int64
+
+	// now is replaced in tests to get stable timestamps
+	now func() time.Time
+
+	mu   sync.Mutex
+	seed *mathrand.Rand
+}
+
+func New(m *model.Model, tok tokenizer.Tokenizer, cfg Config) *Server {
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 1
+	}
+	if cfg.MaxPrompts <= 0 {
+		cfg.MaxPrompts = 1
+	}
+
+	return &Server{
+		cfg:     cfg,
+		model:   m,
+		tok:     tok,
+		slots:   make(chan struct{}, cfg.MaxConcurrent),
+		created: time.Now().Unix(),
+		now:     time.Now,
+		seed:    mathrand.New(mathrand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", s.handleModels)
+	mux.HandleFunc("/v1/completions", s.handleCompletions)
+	mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &apiError{status: http.StatusNotFound, typ: "invalid_request_error", msg: fmt.Sprintf("unknown path %s", r.URL.Path)})
+	})
+
+	return mux
+}
+
+type apiError struct {
+	status int
+	typ    string
+	msg    string
+	param  string
+	code   string
+}
+
+func (e *apiError) Error() string {
+	return e.msg
+}
+
+func invalid(param, format string, args ...any) *apiError {
+	return &apiError{status: http.StatusBadRequest, typ: "invalid_request_error", msg: fmt.Sprintf(format, args...), param: param}
+}
+
+var errQueueFull = &apiError{status: http.StatusTooManyRequests, typ: "server_overloaded", msg: "too many requests are queued, try again later", code: "queue_full"}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *apiError
+	if !errors.As(err, &e) {
+		e = &apiError{status: http.StatusInternalServerError, typ: "server_error", msg: err.Error()}
+	}
+
+	body := ErrorResponse{Error: APIError{Message: e.msg, Type: e.typ}}
+	if e.param != "" {
+		body.Error.Param = &e.param
+	}
+	if e.code != "" {
+		body.Error.Code = &e.code
+	}
+
+	writeJSON(w, e.status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// acquire takes a generation slot, waiting in the queue if none is free.
+func (s *Server) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if s.waiting.Add(1) > int64(s.cfg.MaxQueue) {
+		s.waiting.Add(-1)
+		return errQueueFull
+	}
+	defer s.waiting.Add(-1)
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) release() {
+	<-s.slots
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, &apiError{status: http.StatusMethodNotAllowed, typ: "invalid_request_error", msg: "use GET"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ModelList{
+		Object: "list",
+		Data:   []ModelObject{{ID: s.cfg.ModelID, Object: "model", Created: s.created, OwnedBy: "nanollm"}},
+	})
+}
+
+// decode reads a JSON request body into v after checking the method.
+func decode(r *http.Request, w http.ResponseWriter, v any) error {
+	if r.Method != http.MethodPost {
+		return &apiError{status: http.StatusMethodNotAllowed, typ: "invalid_request_error", msg: "use POST"}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return invalid("", "invalid JSON body: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Server) checkModel(name string) error {
+	if name == "" {
+		return invalid("model", "model is required")
+	}
+	if name != s.cfg.ModelID {
+		return &apiError{status: http.StatusNotFound, typ: "invalid_request_error", msg: fmt.Sprintf("model %q does not exist", name), param: "model", code: "model_not_found"}
+	}
+
+	return nil
+}
+
+// params holds validated sampling settings shared by both endpoints.
+type params struct {
+	opts sample.Options
+	n    int
+	stop []string
+	seed *int64
+}
+
+func (s *Server) params(prompts int, maxTokens *int, temperature, topP *float64, topK, n *int, stop []string, seed *int64) (params, error) {
+	p := params{
+		opts: sample.Options{MaxTokens: s.cfg.DefaultMaxTokens, Temperature: 1},
+		n:    1,
+		stop: stop,
+		seed: seed,
+	}
+
+	if maxTokens != nil {
+		if *maxTokens < 1 || *maxTokens > s.cfg.MaxTokens {
+			return p, invalid("max_tokens", "max_tokens must be between 1 and %d", s.cfg.MaxTokens)
+		}
+		p.opts.MaxTokens = *maxTokens
+	}
+	if temperature != nil {
+		if *temperature < 0 || *temperature > 2 {
+			return p, invalid("temperature", "temperature must be between 0 and 2")
+		}
+		p.opts.Temperature = *temperature
+	}
+	if topP != nil {
+		if *topP <= 0 || *topP > 1 {
+			return p, invalid("top_p", "top_p must be in (0, 1]")
+		}
+		p.opts.TopP = *topP
+	}
+	if topK != nil {
+		if *topK < 0 {
+			return p, invalid("top_k", "top_k must not be negative")
+		}
+		p.opts.TopK = *topK
+	}
+	if n != nil {
+		if *n < 1 || *n > s.cfg.MaxN {
+			return p, invalid("n", "n must be between 1 and %d", s.cfg.MaxN)
+		}
+		p.n = *n
+	}
+	if prompts > s.cfg.MaxPrompts {
+		return p, invalid("prompt", "at most %d prompts are allowed", s.cfg.MaxPrompts)
+	}
+	if len(stop) > 4 {
+		return p, invalid("stop", "at most 4 stop sequences are allowed")
+	}
+	for _, seq := range stop {
+		if seq == "" {
+			return p, invalid("stop", "stop sequences must not be empty")
+		}
+	}
+
+	return p, nil
+}
+
+// rng returns the random source for one request. A request seed makes the
+// whole response reproducible.
+func (s *Server) rng(p params) *mathrand.Rand {
+	if p.seed != nil {
+		return mathrand.New(mathrand.NewSource(*p.seed))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return mathrand.New(mathrand.NewSource(s.seed.Int63()))
+}
+
+func newID(prefix string) string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return prefix + hex.EncodeToString(buf)
+}
@@ -0,0 +1,284 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+func newTestServer(t *testing.T, cfg Config) (*Server, *httptest.Server) {
+	t.Helper()
+	tok := tokenizer.NewChar("abcdefghij klmnopqrstuvwxyz:\n")
+	m, err := model.New(model.Config{VocabSize: tok.VocabSize(), ContextSize: 3, EmbedDim: 4, Hidden: []int{8}}, rand.New(rand.NewSource(5)))
+	require.NoError(t, err)
+
+	s := New(m, tok, cfg)
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+func post(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeBody[T any](t *testing.T, resp *http.Response) T {
+	t.Helper()
+	var v T
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v
+}
+
+// events returns the payloads of the server-sent events in the response.
+func events(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	var out []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			out = append(out, data)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return out
+}
+
+func TestModels(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp, err := http.Get(ts.URL + "/v1/models")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	list := decodeBody[ModelList](t, resp)
+	assert.Equal(t, "list", list.Object)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "nanollm", list.Data[0].ID)
+}
+
+func TestCompletion(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp := post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":"abc","max_tokens":7,"temperature":0}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	out := decodeBody[CompletionResponse](t, resp)
+	assert.Equal(t, "text_completion", out.Object)
+	assert.True(t, strings.HasPrefix(out.ID, "cmpl-"))
+	assert.Equal(t, int64(1700000000), out.Created)
+	require.Len(t, out.Choices, 1)
+	assert.Equal(t, "length", *out.Choices[0].FinishReason)
+	assert.Equal(t, Usage{PromptTokens: 3, CompletionTokens: 7, TotalTokens: 10}, *out.Usage)
+}
+
+func TestCompletionMultiplePromptsAndChoices(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp := post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":["ab","cd"],"n":2,"max_tokens":3,"seed":1}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	out := decodeBody[CompletionResponse](t, resp)
+	require.Len(t, out.Choices, 4)
+	for i, c := range out.Choices {
+		assert.Equal(t, i, c.Index)
+	}
+	assert.Equal(t, 12, out.Usage.CompletionTokens)
+}
+
+func TestCompletionSeedIsReproducible(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+	body := `{"model":"nanollm","prompt":"a","max_tokens":20,"seed":42}`
+
+	first := decodeBody[CompletionResponse](t, post(t, ts.URL+"/v1/completions", body))
+	second := decodeBody[CompletionResponse](t, post(t, ts.URL+"/v1/completions", body))
+
+	assert.Equal(t, first.Choices[0].Text, second.Choices[0].Text)
+}
+
+func TestCompletionStopSequence(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	full := decodeBody[CompletionResponse](t, post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":"abc","max_tokens":30,"temperature":0}`))
+	text := full.Choices[0].Text
+	stop := text[10:12]
+
+	body, err := json.Marshal(map[string]any{"model": "nanollm", "prompt": "abc", "max_tokens": 30, "temperature": 0, "stop": stop})
+	require.NoError(t, err)
+	out := decodeBody[CompletionResponse](t, post(t, ts.URL+"/v1/completions", string(body)))
+
+	assert.Equal(t, text[:strings.Index(text, stop)], out.Choices[0].Text, "Text should end right before the stop sequence")
+	assert.Equal(t, "stop", *out.Choices[0].FinishReason)
+}
+
+func TestCompletionStreamMatchesNonStreamed(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	full := decodeBody[CompletionResponse](t, post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":"abc","max_tokens":30,"temperature":0}`))
+	text := full.Choices[0].Text
+
+	resp := post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":"abc","max_tokens":30,"temperature":0,"stream":true,"stop":"`+text[20:22]+`"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	payloads := events(t, resp)
+	require.NotEmpty(t, payloads)
+	assert.Equal(t, "[DONE]", payloads[len(payloads)-1])
+
+	var streamed strings.Builder
+	var finish string
+	for _, p := range payloads[:len(payloads)-1] {
+		var chunk CompletionResponse
+		require.NoError(t, json.Unmarshal([]byte(p), &chunk))
+		require.Len(t, chunk.Choices, 1)
+		streamed.WriteString(chunk.Choices[0].Text)
+		if chunk.Choices[0].FinishReason != nil {
+			finish = *chunk.Choices[0].FinishReason
+		}
+	}
+
+	assert.Equal(t, text[:strings.Index(text, text[20:22])], streamed.String())
+	assert.Equal(t, "stop", finish)
+}
+
+func TestChatCompletion(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp := post(t, ts.URL+"/v1/chat/completions", `{"model":"nanollm","messages":[{"role":"system","content":"be nice"},{"role":"user","content":"hi"}],"max_tokens":5}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	out := decodeBody[ChatCompletionResponse](t, resp)
+	assert.Equal(t, "chat.completion", out.Object)
+	require.Len(t, out.Choices, 1)
+	assert.Equal(t, "assistant", out.Choices[0].Message.Role)
+	assert.Equal(t, len("system: be nice\nuser: hi\nassistant:"), out.Usage.PromptTokens)
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp := post(t, ts.URL+"/v1/chat/completions", `{"model":"nanollm","messages":[{"role":"user","content":"hi"}],"max_tokens":5,"stream":true}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	payloads := events(t, resp)
+	require.GreaterOrEqual(t, len(payloads), 3)
+	assert.Equal(t, "[DONE]", payloads[len(payloads)-1])
+
+	var first, last ChatCompletionResponse
+	require.NoError(t, json.Unmarshal([]byte(payloads[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(payloads[len(payloads)-2]), &last))
+
+	assert.Equal(t, "chat.completion.chunk", first.Object)
+	assert.Equal(t, "assistant", first.Choices[0].Delta.Role)
+	assert.NotNil(t, last.Choices[0].FinishReason)
+}
+
+func TestValidation(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	cases := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		param  string
+	}{
+		{"bad json", "/v1/completions", `{"model":`, http.StatusBadRequest, ""},
+		{"missing model", "/v1/completions", `{"prompt":"a"}`, http.StatusBadRequest, "model"},
+		{"unknown model", "/v1/completions", `{"model":"gpt-4","prompt":"a"}`, http.StatusNotFound, "model"},
+		{"max tokens", "/v1/completions", `{"model":"nanollm","max_tokens":100000}`, http.StatusBadRequest, "max_tokens"},
+		{"temperature", "/v1/completions", `{"model":"nanollm","temperature":3}`, http.StatusBadRequest, "temperature"},
+		{"top p", "/v1/completions", `{"model":"nanollm","top_p":0}`, http.StatusBadRequest, "top_p"},
+		{"n", "/v1/completions", `{"model":"nanollm","n":100}`, http.StatusBadRequest, "n"},
+		{"too many prompts", "/v1/completions", `{"model":"nanollm","prompt":["a","b","c","d","e","f","g","h","i"]}`, http.StatusBadRequest, "prompt"},
+		{"stop type", "/v1/completions", `{"model":"nanollm","stop":5}`, http.StatusBadRequest, ""},
+		{"too many stops", "/v1/completions", `{"model":"nanollm","stop":["a","b","c","d","e"]}`, http.StatusBadRequest, "stop"},
+		{"no messages", "/v1/chat/completions", `{"model":"nanollm","messages":[]}`, http.StatusBadRequest, "messages"},
+		{"bad role", "/v1/chat/completions", `{"model":"nanollm","messages":[{"role":"robot","content":"x"}]}`, http.StatusBadRequest, "messages[0].role"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := post(t, ts.URL+tc.path, tc.body)
+			assert.Equal(t, tc.status, resp.StatusCode)
+
+			out := decodeBody[ErrorResponse](t, resp)
+			assert.NotEmpty(t, out.Error.Message)
+			if tc.param != "" {
+				require.NotNil(t, out.Error.Param)
+				assert.Equal(t, tc.param, *out.Error.Param)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	_, ts := newTestServer(t, DefaultConfig())
+
+	resp, err := http.Get(ts.URL + "/v1/completions")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestQueueFullIsRejected(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrent = 1
+	cfg.MaxQueue = 0
+	s, ts := newTestServer(t, cfg)
+
+	// Hold the only slot
+	require.NoError(t, s.acquire(context.Background()))
+	defer s.release()
+
+	resp := post(t, ts.URL+"/v1/completions", `{"model":"nanollm","prompt":"a","max_tokens":1}`)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	out := decodeBody[ErrorResponse](t, resp)
+	assert.Equal(t, "queue_full", *out.Error.Code)
+}
+
+func TestQueuedRequestRunsWhenSlotFrees(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxConcurrent = 1
+	cfg.MaxQueue = 1
+	s, ts := newTestServer(t, cfg)
+
+	require.NoError(t, s.acquire(context.Background()))
+
+	done := make(chan int)
+	go func() {
+		resp, err := http.Post(ts.URL+"/v1/completions", "application/json", strings.NewReader(`{"model":"nanollm","prompt":"a","max_tokens":1}`))
+		if err != nil {
+			done <- 0
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+
+	// Wait until the request is queued, then free the slot
+	require.Eventually(t, func() bool { return s.waiting.Load() == 1 }, time.Second, time.Millisecond)
+	s.release()
+
+	assert.Equal(t, http.StatusOK, <-done)
+}
@@ -0,0 +1,139 @@
+package tokenizer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tokenizer maps text to token ids and back.
+type Tokenizer interface {
+	Encode(text string) []int
+	Decode(ids []int) string
+	VocabSize() int
+	Spec() Spec
+}
+
+// Spec is the serializable description of a tokenizer, stored alongside
+// model weights so a checkpoint can be decoded without the training data.
+type Spec struct {
+	Kind  string `json:"kind" yaml:"kind"`
+	Vocab string `json:"vocab,omitempty" yaml:"vocab,omitempty"`
+}
+
+// New builds the tokenizer named by kind, fitting its vocabulary to text
+// when the kind needs one.
+func New(kind, text string) (Tokenizer, error) {
+	switch kind {
+	case "char", "":
+		return NewChar(text), nil
+	case "byte":
+		return Byte{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q", kind)
+	}
+}
+
+// FromSpec restores a tokenizer saved with Spec.
+func FromSpec(spec Spec) (Tokenizer, error) {
+	switch spec.Kind {
+	case "char":
+		if spec.Vocab == "" {
+			return nil, fmt.Errorf("char tokenizer spec has an empty vocabulary")
+		}
+		return NewChar(spec.Vocab), nil
+	case "byte":
+		return Byte{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q", spec.Kind)
+	}
+}
+
+// Char is a character level tokenizer whose vocabulary is the sorted set of
+// runes seen in the text it was built from.
+type Char struct {
+	runes []rune
+	ids   map[rune]int
+}
+
+func NewChar(text string) *Char {
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		seen[r] = true
+	}
+
+	runes := make([]rune, 0, len(seen))
+	for r := range seen {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	ids := make(map[rune]int, len(runes))
+	for i, r := range runes {
+		ids[r] = i
+	}
+
+	return &Char{runes: runes, ids: ids}
+}
+
+// Encode drops runes that are not in the vocabulary.
+func (c *Char) Encode(text string) []int {
+	out := make([]int, 0, len(text))
+	for _, r := range text {
+		if id, ok := c.ids[r]; ok {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+func (c *Char) Decode(ids []int) string {
+	var sb strings.Builder
+	for _, id := range ids {
+		if id >= 0 && id < len(c.runes) {
+			sb.WriteRune(c.runes[id])
+		}
+	}
+
+	return sb.String()
+}
+
+func (c *Char) VocabSize() int {
+	return len(c.runes)
+}
+
+func (c *Char) Spec() Spec {
+	return Spec{Kind: "char", Vocab: string(c.runes)}
+}
+
+// Byte encodes text as raw UTF-8 bytes, so every input is representable.
+type Byte struct{}
+
+func (Byte) Encode(text string) []int {
+	out := make([]int, len(text))
+	for i := 0; i < len(text); i++ {
+		out[i] = int(text[i])
+	}
+
+	return out
+}
+
+func (Byte) Decode(ids []int) string {
+	buf := make([]byte, 0, len(ids))
+	for _, id := range ids {
+		if id >= 0 && id < 256 {
+			buf = append(buf, byte(id))
+		}
+	}
+
+	return string(buf)
+}
+
+func (Byte) VocabSize() int {
+	return 256
+}
+
+func (Byte) Spec() Spec {
+	return Spec{Kind: "byte"}
+}
@@ -0,0 +1,48 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCharRoundTrip(t *testing.T) {
+	tok := NewChar("hello world")
+
+	assert.Equal(t, 8, tok.VocabSize(), "Vocabulary should hold the distinct runes")
+
+	ids := tok.Encode("hello")
+	assert.Equal(t, "hello", tok.Decode(ids), "Decode should invert Encode")
+}
+
+func TestCharDropsUnknownRunes(t *testing.T) {
+	tok := NewChar("abc")
+
+	assert.Equal(t, "ab", tok.Decode(tok.Encode("axbz")), "Unknown runes should be dropped")
+}
+
+func TestCharSpecRoundTrip(t *testing.T) {
+	tok := NewChar("naïve café")
+
+	restored, err := FromSpec(tok.Spec())
+	require.NoError(t, err)
+
+	assert.Equal(t, tok.Encode("café"), restored.Encode("café"), "Restored tokenizer should assign the same ids")
+}
+
+func TestByteRoundTrip(t *testing.T) {
+	tok, err := New("byte", "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 256, tok.VocabSize())
+	assert.Equal(t, "héllo", tok.Decode(tok.Encode("héllo")), "Byte tokenizer should round trip UTF-8")
+}
+
+func TestUnknownKind(t *testing.T) {
+	_, err := New("bpe", "")
+	assert.Error(t, err)
+
+	_, err = FromSpec(Spec{Kind: "char"})
+	assert.Error(t, err, "Char spec without a vocabulary should be rejected")
+}
@@ -0,0 +1,165 @@
+package train
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/optim"
+)
+
+// Config is the YAML description of a training run. See
+// configs/tiny.yaml for an annotated example.
+type Config struct {
+	Data          DataConfig           `yaml:"data"`
+	Tokenizer     string               `yaml:"tokenizer"`
+	Model         model.Config         `yaml:"model"`
+	Optimizer     optim.Config         `yaml:"optimizer"`
+	Schedule      optim.ScheduleConfig `yaml:"schedule"`
+	Steps         int                  `yaml:"steps"`
+	BatchSize     int                  `yaml:"batch_size"`
+	EvalInterval  int                  `yaml:"eval_interval"`
+	EvalBatches   int                  `yaml:"eval_batches"`
+	CheckpointDir string               `yaml:"checkpoint_dir"`
+	// Metrics lists the files metrics are written to, JSONL or CSV by
+	// extension. Relative paths are inside CheckpointDir.
+	Metrics []string `yaml:"metrics"`
+	Seed    int64    `yaml:"seed"`
+	// DetectAnomaly stops training with an error at the first NaN or Inf in
+	// the forward or backward pass. It makes steps much slower.
+	DetectAnomaly bool `yaml:"detect_anomaly"`
+	// Workers is the number of goroutines each batch is split across, each
+	// with its own copy of the model.
+	Workers int `yaml:"workers"`
+	// GradAccum splits each batch into this many micro-batches whose
+	// gradients are summed before the optimizer step, trading speed for
+	// the memory of one micro-batch's graph.
+	GradAccum int `yaml:"grad_accum"`
+	// Precision switches on mixed precision training.
+	Precision PrecisionConfig `yaml:"precision"`
+}
+
+// PrecisionConfig describes mixed precision training. With activations
+// below float64 the layer activations and their gradients are rounded to
+// that dtype, the weights are kept in float32, and the loss is scaled up so
+// small gradients survive the rounding. A step whose gradients overflow is
+// skipped and the scale halved; after ScaleWindow steps in a row without
+// overflow the scale doubles.
+type PrecisionConfig struct {
+	Activations string  `yaml:"activations"`
+	LossScale   float64 `yaml:"loss_scale"`
+	ScaleWindow int     `yaml:"scale_window"`
+}
+
+// Mixed reports whether the config asks for mixed precision.
+func (c PrecisionConfig) Mixed() bool {
+	return c.Activations != "" && c.Activations != dtype.Float64.String()
+}
+
+type DataConfig struct {
+	Path     string  `yaml:"path"`
+	ValSplit float64 `yaml:"val_split"`
+}
+
+// DefaultConfig is a model small enough to train on the scalar engine in a
+// few minutes.
+func DefaultConfig() Config {
+	return Config{
+		Data:          DataConfig{ValSplit: 0.1},
+		Tokenizer:     "char",
+		Model:         model.Config{ContextSize: 8, EmbedDim: 8, Hidden: []int{32}},
+		Optimizer:     optim.Config{Name: "adamw", LR: 0.01, GradClip: 1.0},
+		Schedule:      optim.ScheduleConfig{Name: "cosine", WarmupSteps: 10, MinLR: 0.001},
+		Steps:         500,
+		BatchSize:     16,
+		EvalInterval:  50,
+		EvalBatches:   4,
+		CheckpointDir: "checkpoints",
+		Metrics:       []string{"metrics.jsonl"},
+		Seed:          1337,
+		Workers:       1,
+		GradAccum:     1,
+		Precision:     PrecisionConfig{Activations: "float64", LossScale: 65536, ScaleWindow: 1000},
+	}
+}
+
+// LoadConfig reads a YAML config on top of DefaultConfig. Unknown keys are
+// rejected so typos do not silently fall back to defaults.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c Config) Validate() error {
+	if c.Data.Path == "" {
+		return fmt.Errorf("data.path is required")
+	}
+	if c.Data.ValSplit < 0 || c.Data.ValSplit >= 1 {
+		return fmt.Errorf("data.val_split must be in [0, 1), got %g", c.Data.ValSplit)
+	}
+	if c.Steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", c.Steps)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch_size must be positive, got %d", c.BatchSize)
+	}
+	if c.Workers <= 0 {
+		return fmt.Errorf("workers must be positive, got %d", c.Workers)
+	}
+	if c.GradAccum <= 0 || c.GradAccum > c.BatchSize {
+		return fmt.Errorf("grad_accum must be between 1 and batch_size (%d), got %d", c.BatchSize, c.GradAccum)
+	}
+	if _, err := dtype.Parse(c.Precision.Activations); err != nil {
+		return fmt.Errorf("precision.activations: %w", err)
+	}
+	if c.Precision.Mixed() && c.Precision.LossScale <= 0 {
+		return fmt.Errorf("precision.loss_scale must be positive, got %g", c.Precision.LossScale)
+	}
+	if c.Precision.Mixed() && c.Precision.ScaleWindow <= 0 {
+		return fmt.Errorf("precision.scale_window must be positive, got %d", c.Precision.ScaleWindow)
+	}
+	if c.EvalInterval < 0 {
+		return fmt.Errorf("eval_interval must not be negative, got %d", c.EvalInterval)
+	}
+	for _, path := range c.Metrics {
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".jsonl" && ext != ".csv" {
+			return fmt.Errorf("metrics file %s must end in .jsonl or .csv", path)
+		}
+	}
+	if c.Optimizer.LR <= 0 {
+		return fmt.Errorf("optimizer.lr must be positive, got %g", c.Optimizer.LR)
+	}
+
+	return nil
+}
+
+// MetricsPaths resolves Metrics against CheckpointDir.
+func (c Config) MetricsPaths() []string {
+	paths := make([]string, len(c.Metrics))
+	for i, path := range c.Metrics {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.CheckpointDir, path)
+		}
+		paths[i] = path
+	}
+
+	return paths
+}
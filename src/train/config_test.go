@@ -0,0 +1,85 @@
+package train
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	return path
+}
+
+func TestLoadConfigOverridesDefaults(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+data:
+  path: input.txt
+model:
+  context_size: 4
+  hidden: [16, 16]
+optimizer:
+  name: sgd
+  lr: 0.5
+steps: 20
+`)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "input.txt", cfg.Data.Path)
+	assert.Equal(t, 4, cfg.Model.ContextSize)
+	assert.Equal(t, []int{16, 16}, cfg.Model.Hidden)
+	assert.Equal(t, "sgd", cfg.Optimizer.Name)
+	assert.Equal(t, 0.5, cfg.Optimizer.LR)
+	assert.Equal(t, 20, cfg.Steps)
+
+	// Keys missing from the file keep their defaults
+	assert.Equal(t, DefaultConfig().Model.EmbedDim, cfg.Model.EmbedDim)
+	assert.Equal(t, DefaultConfig().BatchSize, cfg.BatchSize)
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	path := writeFile(t, "config.yaml", "batch_szie: 8\n")
+
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+}
+
+func TestExampleConfigLoads(t *testing.T) {
+	cfg, err := LoadConfig("../../configs/tiny.yaml")
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Error(t, cfg.Validate(), "A config without a data path should be invalid")
+
+	cfg.Data.Path = "input.txt"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.BatchSize = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg.BatchSize = 1
+	cfg.Workers = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg.Workers = 1
+	cfg.GradAccum = 2
+	assert.Error(t, cfg.Validate(), "More micro-batches than examples should be invalid")
+
+	cfg.GradAccum = 1
+	cfg.Precision.Activations = "float8"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Precision.Activations = "float16"
+	cfg.Precision.LossScale = 0
+	assert.Error(t, cfg.Validate())
+}
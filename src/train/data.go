@@ -0,0 +1,53 @@
+package train
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+// Dataset is a tokenized corpus split into a training and validation part.
+type Dataset struct {
+	Train []int
+	Val   []int
+}
+
+// LoadDataset reads a text file, fits a tokenizer to it and holds out the
+// final valSplit fraction of tokens for validation.
+func LoadDataset(path, tokenizerKind string, valSplit float64) (*Dataset, tokenizer.Tokenizer, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tok, err := tokenizer.New(tokenizerKind, string(text))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := tok.Encode(string(text))
+	split := len(ids) - int(float64(len(ids))*valSplit)
+
+	return &Dataset{Train: ids[:split], Val: ids[split:]}, tok, nil
+}
+
+// Batch samples size random windows from tokens. Each context holds
+// contextSize consecutive tokens and its target is the token that follows.
+func Batch(tokens []int, contextSize, size int, rng *rand.Rand) ([][]int, []int, error) {
+	windows := len(tokens) - contextSize
+	if windows <= 0 {
+		return nil, nil, fmt.Errorf("need more than %d tokens to sample a batch, have %d", contextSize, len(tokens))
+	}
+
+	contexts := make([][]int, size)
+	targets := make([]int, size)
+	for i := range contexts {
+		start := rng.Intn(windows)
+		contexts[i] = tokens[start : start+contextSize]
+		targets[i] = tokens[start+contextSize]
+	}
+
+	return contexts, targets, nil
+}
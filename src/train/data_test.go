@@ -0,0 +1,40 @@
+package train
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDatasetSplits(t *testing.T) {
+	path := writeFile(t, "input.txt", "abcdefghij")
+
+	data, tok, err := LoadDataset(path, "char", 0.2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, tok.VocabSize())
+	assert.Equal(t, "abcdefgh", tok.Decode(data.Train))
+	assert.Equal(t, "ij", tok.Decode(data.Val))
+}
+
+func TestBatchWindows(t *testing.T) {
+	tokens := []int{0, 1, 2, 3, 4, 5}
+
+	contexts, targets, err := Batch(tokens, 3, 10, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+
+	require.Len(t, contexts, 10)
+	for i, ctx := range contexts {
+		require.Len(t, ctx, 3)
+		// Tokens are consecutive integers, so each window and its target follow on
+		assert.Equal(t, ctx[0]+1, ctx[1])
+		assert.Equal(t, ctx[2]+1, targets[i])
+	}
+}
+
+func TestBatchTooShort(t *testing.T) {
+	_, _, err := Batch([]int{0, 1}, 2, 1, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package train
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+	"github.com/Grimkey/nanollm/src/model"
+)
+
+// Data-parallel steps split each micro-batch into one contiguous shard per
+// worker. Worker 0 trains Trainer.Model itself and the others train clones
+// holding a copy of its weights. Every worker scales its shard's mean loss
+// by its share of the batch, so the worker gradients add up to the gradient
+// of the whole batch's mean loss. They are summed into Trainer.Model by a
+// tree reduction before the optimizer steps once. The result matches a
+// single worker up to the order floating point sums are taken in.
+
+type replica struct {
+	model  *model.Model
+	params []*micrograd.Value
+}
+
+func newReplicas(m *model.Model, workers int) []replica {
+	replicas := []replica{{model: m, params: m.Parameters()}}
+	for w := 1; w < workers; w++ {
+		clone := m.Clone()
+		replicas = append(replicas, replica{model: clone, params: clone.Parameters()})
+	}
+
+	return replicas
+}
+
+// shard returns the range of examples worker w of workers gets out of n.
+// Sizes differ by at most one.
+func shard(n, workers, w int) (lo, hi int) {
+	size, extra := n/workers, n%workers
+	lo = w*size + min(w, extra)
+	hi = lo + size
+	if w < extra {
+		hi++
+	}
+
+	return lo, hi
+}
+
+// parallelLoss runs forward and backward for the examples across the
+// replicas, scaling their mean loss by scale, and adds the gradient to the
+// first replica's. It returns the scaled loss.
+func (t *Trainer) parallelLoss(contexts [][]int, targets []int, scale float64) (float64, error) {
+	master := t.replicas[0].params
+	for _, r := range t.replicas[1:] {
+		micrograd.CopyData(r.params, master)
+		micrograd.ZeroGrad(r.model)
+	}
+
+	losses := make([]float64, len(t.replicas))
+	errs := make([]error, len(t.replicas))
+	var wg sync.WaitGroup
+	for w, r := range t.replicas {
+		lo, hi := shard(len(contexts), len(t.replicas), w)
+		if lo == hi {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			forwardBackward := func() {
+				share := scale * float64(hi-lo) / float64(len(contexts))
+				out := r.model.DenseLoss(contexts[lo:hi], targets[lo:hi]).MulScalar(share)
+				out.Backward()
+				losses[w] = out.Data()
+			}
+			if t.Config.DetectAnomaly {
+				if err := micrograd.DetectAnomaly(forwardBackward); err != nil {
+					errs[w] = fmt.Errorf("worker %d: %w", w, err)
+				}
+			} else {
+				forwardBackward()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+
+	reduceTree(t.replicas)
+
+	var loss float64
+	for _, l := range losses {
+		loss += l
+	}
+	return loss, nil
+}
+
+// reduceTree sums every replica's gradients into the first. Pairs at
+// distance 1, then 2, 4, ... are reduced concurrently, so it takes log2 of
+// the worker count rounds, and the fixed pairing makes the result
+// independent of scheduling.
+func reduceTree(replicas []replica) {
+	for stride := 1; stride < len(replicas); stride *= 2 {
+		var wg sync.WaitGroup
+		for i := 0; i+stride < len(replicas); i += 2 * stride {
+			wg.Add(1)
+			go func(dst, src []*micrograd.Value) {
+				defer wg.Done()
+				micrograd.ReduceGrads(dst, src)
+			}(replicas[i].params, replicas[i+stride].params)
+		}
+		wg.Wait()
+	}
+}
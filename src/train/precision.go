@@ -0,0 +1,57 @@
+package train
+
+import (
+	"math"
+
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+// lossScaler implements dynamic loss scaling for mixed precision. The loss
+// is multiplied by scale before backward so gradients stay above the
+// activation dtype's underflow threshold, and the parameter gradients are
+// divided by it again before the optimizer sees them.
+type lossScaler struct {
+	scale   float64
+	window  int
+	good    int // steps since the last overflow or growth
+	skipped int
+}
+
+func newLossScaler(cfg PrecisionConfig) *lossScaler {
+	return &lossScaler{scale: cfg.LossScale, window: cfg.ScaleWindow}
+}
+
+// unscale divides the gradients by the scale and rounds them to the master
+// weights' float32. If any gradient overflowed it leaves them alone, halves
+// the scale and returns false: the step must be skipped. Otherwise the
+// scale doubles after window good steps in a row.
+func (s *lossScaler) unscale(params []*micrograd.Value) bool {
+	for _, p := range params {
+		if g := p.Grad(); math.IsNaN(g) || math.IsInf(g, 0) {
+			s.scale /= 2
+			s.good = 0
+			s.skipped++
+			return false
+		}
+	}
+
+	for _, p := range params {
+		p.SetGrad(dtype.Float32.Round(p.Grad() / s.scale))
+	}
+
+	s.good++
+	if s.good == s.window {
+		s.scale *= 2
+		s.good = 0
+	}
+	return true
+}
+
+// roundData rounds the parameters to d, which is how master weights are
+// kept in float32 while the engine computes in float64.
+func roundData(params []*micrograd.Value, d dtype.DType) {
+	for _, p := range params {
+		p.SetData(d.Round(p.Data()))
+	}
+}
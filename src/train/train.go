@@ -0,0 +1,286 @@
+package train
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/Grimkey/nanollm/src/checkpoint"
+	"github.com/Grimkey/nanollm/src/dtype"
+	"github.com/Grimkey/nanollm/src/metrics"
+	"github.com/Grimkey/nanollm/src/micrograd"
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/optim"
+	"github.com/Grimkey/nanollm/src/tokenizer"
+)
+
+type Trainer struct {
+	Config    Config
+	Model     *model.Model
+	Tokenizer tokenizer.Tokenizer
+	Data      *Dataset
+	// Metrics receives the run metadata, a record for every step and one for
+	// every evaluation. The trainer does not close it.
+	Metrics metrics.Logger
+
+	optimizer optim.Optimizer
+	schedule  optim.Schedule
+	replicas  []replica   // set when Config.Workers > 1
+	scaler    *lossScaler // set for mixed precision
+	rng       *rand.Rand
+	evalSet   [][][]int
+	evalTgts  [][]int
+}
+
+type Result struct {
+	Steps       int
+	TrainLoss   float64
+	ValLoss     float64
+	BestValLoss float64
+	// SkippedSteps counts mixed precision steps skipped because the
+	// gradients overflowed.
+	SkippedSteps int
+}
+
+// New loads the dataset named in cfg and builds a freshly initialized model
+// sized to its tokenizer. A nil logger discards metrics.
+func New(cfg Config, logger metrics.Logger) (*Trainer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, tok, err := LoadDataset(cfg.Data.Path, cfg.Tokenizer, cfg.Data.ValSplit)
+	if err != nil {
+		return nil, err
+	}
+
+	if logger == nil {
+		logger = metrics.Discard
+	}
+
+	cfg.Model.VocabSize = tok.VocabSize()
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	m, err := model.New(cfg.Model, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	opt, err := optim.New(cfg.Optimizer, m.Parameters())
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := optim.NewSchedule(cfg.Schedule, cfg.Optimizer.LR, cfg.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Trainer{
+		Config:    cfg,
+		Model:     m,
+		Tokenizer: tok,
+		Data:      data,
+		Metrics:   logger,
+		optimizer: opt,
+		schedule:  schedule,
+		rng:       rng,
+	}
+	if cfg.Precision.Mixed() {
+		// Validate has checked the name
+		m.Activations, _ = dtype.Parse(cfg.Precision.Activations)
+		roundData(m.Parameters(), dtype.Float32)
+		t.scaler = newLossScaler(cfg.Precision)
+	}
+	if cfg.Workers > 1 {
+		t.replicas = newReplicas(m, cfg.Workers)
+	}
+
+	// Validation uses the same batches every time so losses are comparable
+	// across evaluations. Run evaluates at the end even when EvalInterval is
+	// 0, so the set is built whenever there is validation data.
+	if len(data.Val) > 0 {
+		evalRng := rand.New(rand.NewSource(cfg.Seed + 1))
+		for i := 0; i < max(cfg.EvalBatches, 1); i++ {
+			contexts, targets, err := Batch(data.Val, cfg.Model.ContextSize, cfg.BatchSize, evalRng)
+			if err != nil {
+				return nil, fmt.Errorf("validation split: %w", err)
+			}
+			t.evalSet = append(t.evalSet, contexts)
+			t.evalTgts = append(t.evalTgts, targets)
+		}
+	}
+
+	return t, nil
+}
+
+// Step runs one optimizer step on a freshly sampled batch, split into
+// Config.GradAccum micro-batches, and returns the training loss, learning
+// rate and pre-clipping gradient norm. A mixed precision step whose
+// gradients overflow is skipped and reports an infinite gradient norm.
+func (t *Trainer) Step(step int) (loss, lr, gradNorm float64, err error) {
+	contexts, targets, err := Batch(t.Data.Train, t.Config.Model.ContextSize, t.Config.BatchSize, t.rng)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	// Micro-batches add their gradients up, each scaled by its share of the
+	// batch, so only one micro-batch's graph is alive at a time. Clipping
+	// and the learning rate apply to the summed gradient.
+	lossScale := 1.0
+	if t.scaler != nil {
+		lossScale = t.scaler.scale
+	}
+
+	micrograd.ZeroGrad(t.Model)
+	for k := 0; k < t.Config.GradAccum; k++ {
+		lo, hi := shard(len(contexts), t.Config.GradAccum, k)
+		scale := lossScale * float64(hi-lo) / float64(len(contexts))
+
+		var microLoss float64
+		if t.replicas != nil {
+			microLoss, err = t.parallelLoss(contexts[lo:hi], targets[lo:hi], scale)
+		} else {
+			microLoss, err = t.loss(contexts[lo:hi], targets[lo:hi], scale)
+		}
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("step %d: %w", step, err)
+		}
+		loss += microLoss / lossScale
+	}
+
+	params := t.Model.Parameters()
+	lr = t.schedule.LR(step)
+	if t.scaler != nil && !t.scaler.unscale(params) {
+		return loss, lr, math.Inf(1), nil
+	}
+
+	gradNorm = optim.ClipGradNorm(params, t.Config.Optimizer.GradClip)
+	t.optimizer.Step(lr)
+	if t.scaler != nil {
+		roundData(params, dtype.Float32)
+	}
+
+	return loss, lr, gradNorm, nil
+}
+
+// loss runs forward and backward for the examples on the trainer's model,
+// scaling their mean loss by scale, and returns the scaled loss.
+func (t *Trainer) loss(contexts [][]int, targets []int, scale float64) (float64, error) {
+	var out *micrograd.Value
+	forwardBackward := func() {
+		out = t.Model.DenseLoss(contexts, targets)
+		if scale != 1 {
+			out = out.MulScalar(scale)
+		}
+		out.Backward()
+	}
+	if t.Config.DetectAnomaly {
+		if err := micrograd.DetectAnomaly(forwardBackward); err != nil {
+			return 0, err
+		}
+	} else {
+		forwardBackward()
+	}
+
+	return out.Data(), nil
+}
+
+// Evaluate returns the mean loss over the fixed validation batches, or NaN
+// when there is no validation data.
+func (t *Trainer) Evaluate() float64 {
+	if len(t.evalSet) == 0 {
+		return math.NaN()
+	}
+
+	var total float64
+	for i, contexts := range t.evalSet {
+		total += t.Model.LossData(contexts, t.evalTgts[i])
+	}
+
+	return total / float64(len(t.evalSet))
+}
+
+// Run trains for Config.Steps steps, evaluating and checkpointing every
+// EvalInterval steps and once more at the end. Cancelling ctx stops after
+// the current step and still writes a final checkpoint.
+func (t *Trainer) Run(ctx context.Context) (Result, error) {
+	res := Result{ValLoss: math.NaN(), BestValLoss: math.Inf(1)}
+	if err := t.Metrics.Start(metrics.NewRun(t.Config, t.Config.Seed)); err != nil {
+		return res, fmt.Errorf("metrics: %w", err)
+	}
+
+	var mem runtime.MemStats
+	for step := 0; step < t.Config.Steps; step++ {
+		start := time.Now()
+		loss, lr, gradNorm, err := t.Step(step)
+		if err != nil {
+			return res, err
+		}
+		elapsed := time.Since(start)
+		res.Steps = step + 1
+		res.TrainLoss = loss
+
+		// Each example in a batch predicts one token
+		runtime.ReadMemStats(&mem)
+		values := map[string]float64{
+			metrics.Loss:         loss,
+			metrics.LR:           lr,
+			metrics.GradNorm:     gradNorm,
+			metrics.TokensPerSec: float64(t.Config.BatchSize) / elapsed.Seconds(),
+			metrics.StepTimeMS:   float64(elapsed.Microseconds()) / 1000,
+			metrics.HeapBytes:    float64(mem.HeapAlloc),
+		}
+		if t.scaler != nil {
+			values[metrics.LossScale] = t.scaler.scale
+			res.SkippedSteps = t.scaler.skipped
+		}
+		if err := t.Metrics.Log(metrics.Record{Step: step, Values: values}); err != nil {
+			return res, fmt.Errorf("metrics: %w", err)
+		}
+
+		last := step == t.Config.Steps-1 || ctx.Err() != nil
+		if (t.Config.EvalInterval > 0 && (step+1)%t.Config.EvalInterval == 0) || last {
+			if err := t.evalAndSave(&res); err != nil {
+				return res, err
+			}
+		}
+
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+	}
+
+	return res, nil
+}
+
+func (t *Trainer) evalAndSave(res *Result) error {
+	res.ValLoss = t.Evaluate()
+	if !math.IsNaN(res.ValLoss) {
+		rec := metrics.Record{Step: res.Steps - 1, Values: map[string]float64{metrics.ValLoss: res.ValLoss}}
+		if err := t.Metrics.Log(rec); err != nil {
+			return fmt.Errorf("metrics: %w", err)
+		}
+	}
+
+	if t.Config.CheckpointDir == "" {
+		return nil
+	}
+
+	ck := checkpoint.New(t.Model, t.Tokenizer, res.Steps, res.ValLoss)
+	if err := checkpoint.Save(filepath.Join(t.Config.CheckpointDir, "latest.json"), ck); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+
+	if res.ValLoss < res.BestValLoss {
+		res.BestValLoss = res.ValLoss
+		if err := checkpoint.Save(filepath.Join(t.Config.CheckpointDir, "best.json"), ck); err != nil {
+			return fmt.Errorf("save checkpoint: %w", err)
+		}
+	}
+
+	return nil
+}
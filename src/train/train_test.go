@@ -0,0 +1,357 @@
+package train
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/checkpoint"
+	"github.com/Grimkey/nanollm/src/metrics"
+	"github.com/Grimkey/nanollm/src/micrograd"
+	"github.com/Grimkey/nanollm/src/model"
+	"github.com/Grimkey/nanollm/src/plot"
+)
+
+func tinyRun(t *testing.T) Config {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Data.Path = writeFile(t, "input.txt", strings.Repeat("abcabd", 50))
+	cfg.Data.ValSplit = 0.2
+	cfg.Model = model.Config{ContextSize: 3, EmbedDim: 3, Hidden: []int{8}}
+	cfg.Optimizer.LR = 0.05
+	cfg.Steps = 40
+	cfg.BatchSize = 8
+	cfg.EvalInterval = 20
+	cfg.EvalBatches = 1
+	cfg.CheckpointDir = filepath.Join(t.TempDir(), "ckpt")
+	return cfg
+}
+
+func TestRunLearnsAndCheckpoints(t *testing.T) {
+	cfg := tinyRun(t)
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	initial := tr.Evaluate()
+
+	res, err := tr.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, cfg.Steps, res.Steps)
+	assert.Less(t, res.ValLoss, initial, "Validation loss should improve over training")
+
+	for _, name := range []string{"latest.json", "best.json"} {
+		_, err := os.Stat(filepath.Join(cfg.CheckpointDir, name))
+		assert.NoError(t, err, "Expected %s to be written", name)
+	}
+
+	ck, err := checkpoint.Load(filepath.Join(cfg.CheckpointDir, "latest.json"))
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Steps, ck.Step)
+
+	m, _, err := ck.Restore()
+	require.NoError(t, err)
+	assert.Equal(t, tr.Model.Forward([]int{0, 1, 2})[0].Data(), m.Forward([]int{0, 1, 2})[0].Data())
+}
+
+func TestRunWritesMetrics(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+
+	var buf bytes.Buffer
+	mem := &metrics.Memory{}
+	tr, err := New(cfg, metrics.Multi(metrics.NewJSONL(&buf), mem))
+	require.NoError(t, err)
+
+	_, err = tr.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, cfg.Seed, mem.Run.Seed)
+	assert.Equal(t, metrics.ConfigHash(tr.Config), mem.Run.ConfigHash)
+	assert.NotEmpty(t, mem.Run.GitRevision)
+	steps, tps := mem.Series(metrics.TokensPerSec)
+	assert.Len(t, steps, cfg.Steps)
+	for _, v := range tps {
+		assert.Greater(t, v, 0.0)
+	}
+	_, heap := mem.Series(metrics.HeapBytes)
+	assert.Len(t, heap, cfg.Steps)
+
+	// The JSONL sink must stay readable by nanollm plot
+	m, err := plot.ReadMetrics(&buf)
+	require.NoError(t, err)
+	assert.Len(t, m["loss"], cfg.Steps)
+	assert.Len(t, m["lr"], cfg.Steps)
+	assert.Len(t, m["grad_norm"], cfg.Steps)
+	assert.Equal(t, []float64{19, 39}, []float64{m["val_loss"][0].X, m["val_loss"][1].X})
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	cfg := tinyRun(t)
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := tr.Run(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, res.Steps, "A cancelled run should stop after the current step")
+
+	_, err = os.Stat(filepath.Join(cfg.CheckpointDir, "latest.json"))
+	assert.NoError(t, err, "A cancelled run should still checkpoint")
+}
+
+func TestEvalIntervalZeroEvaluatesAtEnd(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.EvalInterval = 0
+	cfg.Steps = 5
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	res, err := tr.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.False(t, math.IsNaN(res.ValLoss), "The final evaluation should have validation data")
+	_, err = os.Stat(filepath.Join(cfg.CheckpointDir, "best.json"))
+	assert.NoError(t, err)
+}
+
+func TestSameSeedIsDeterministic(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.Steps = 5
+	cfg.CheckpointDir = ""
+
+	var losses [2]float64
+	for i := range losses {
+		tr, err := New(cfg, nil)
+		require.NoError(t, err)
+		res, err := tr.Run(context.Background())
+		require.NoError(t, err)
+		losses[i] = res.TrainLoss
+	}
+
+	assert.Equal(t, losses[0], losses[1])
+}
+
+func TestEvaluateBuildsNoGraph(t *testing.T) {
+	cfg := tinyRun(t)
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, tr.evalSet)
+
+	withGraph := testing.AllocsPerRun(5, func() { tr.Model.Loss(tr.evalSet[0], tr.evalTgts[0]) })
+	evaluate := testing.AllocsPerRun(5, func() { tr.Evaluate() })
+
+	assert.Less(t, evaluate, withGraph/2, "Evaluate should not record a graph")
+}
+
+func TestDetectAnomalyStopsOnNaN(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+	cfg.DetectAnomaly = true
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	tr.Model.Parameters()[0].SetData(math.NaN())
+
+	_, _, _, err = tr.Step(0)
+	var anomaly *micrograd.AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+	assert.Equal(t, "forward", anomaly.Phase)
+}
+
+func TestWorkersMatchSingleWorker(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+	cfg.Steps = 10
+
+	run := func(workers int) ([]float64, []*micrograd.Value) {
+		cfg.Workers = workers
+		tr, err := New(cfg, nil)
+		require.NoError(t, err)
+
+		var losses []float64
+		for step := 0; step < cfg.Steps; step++ {
+			loss, _, _, err := tr.Step(step)
+			require.NoError(t, err)
+			losses = append(losses, loss)
+		}
+		return losses, tr.Model.Parameters()
+	}
+
+	wantLosses, wantParams := run(1)
+	// 3 workers split the batch of 8 unevenly and 11 leave some idle.
+	for _, workers := range []int{2, 3, 11} {
+		losses, params := run(workers)
+		assert.InDeltaSlice(t, wantLosses, losses, 1e-12, "%d workers", workers)
+		for i, p := range params {
+			require.InDelta(t, wantParams[i].Data(), p.Data(), 1e-12, "%d workers, param %d", workers, i)
+		}
+	}
+}
+
+func TestGradAccumMatchesLargeBatch(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+	cfg.Steps = 10
+	cfg.Schedule.WarmupSteps = 3
+	require.NotZero(t, cfg.Optimizer.GradClip, "Clipping should see the accumulated gradient")
+
+	type result struct {
+		losses, norms, lrs []float64
+		params             []*micrograd.Value
+	}
+	run := func(accum, workers int) result {
+		cfg.GradAccum, cfg.Workers = accum, workers
+		tr, err := New(cfg, nil)
+		require.NoError(t, err)
+
+		var r result
+		for step := 0; step < cfg.Steps; step++ {
+			loss, lr, norm, err := tr.Step(step)
+			require.NoError(t, err)
+			r.losses = append(r.losses, loss)
+			r.lrs = append(r.lrs, lr)
+			r.norms = append(r.norms, norm)
+		}
+		r.params = tr.Model.Parameters()
+		return r
+	}
+
+	want := run(1, 1)
+	// 3 micro-batches of a batch of 8 are uneven; 8 is one example each.
+	for _, c := range []struct{ accum, workers int }{{2, 1}, {3, 1}, {8, 1}, {3, 2}} {
+		got := run(c.accum, c.workers)
+		assert.InDeltaSlice(t, want.losses, got.losses, 1e-12, "%+v losses", c)
+		assert.InDeltaSlice(t, want.norms, got.norms, 1e-12, "%+v gradient norms", c)
+		assert.Equal(t, want.lrs, got.lrs, "%+v learning rates", c)
+		for i, p := range got.params {
+			require.InDelta(t, want.params[i].Data(), p.Data(), 1e-12, "%+v param %d", c, i)
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	var sizes []int
+	next := 0
+	for w := 0; w < 3; w++ {
+		lo, hi := shard(8, 3, w)
+		assert.Equal(t, next, lo, "Shards should be contiguous")
+		sizes = append(sizes, hi-lo)
+		next = hi
+	}
+	assert.Equal(t, []int{3, 3, 2}, sizes)
+	assert.Equal(t, 8, next)
+}
+
+func TestWorkersDetectAnomaly(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+	cfg.DetectAnomaly = true
+	cfg.Workers = 4
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	tr.Model.Parameters()[0].SetData(math.NaN())
+
+	_, _, _, err = tr.Step(0)
+	var anomaly *micrograd.AnomalyError
+	require.ErrorAs(t, err, &anomaly)
+}
+
+func TestMixedPrecisionLearns(t *testing.T) {
+	for _, activations := range []string{"float16", "bfloat16"} {
+		t.Run(activations, func(t *testing.T) {
+			cfg := tinyRun(t)
+			cfg.CheckpointDir = ""
+			cfg.Precision.Activations = activations
+
+			tr, err := New(cfg, nil)
+			require.NoError(t, err)
+			initial := tr.Evaluate()
+
+			res, err := tr.Run(context.Background())
+			require.NoError(t, err)
+			assert.Less(t, res.ValLoss, initial, "Validation loss should improve over training")
+			assert.Zero(t, res.SkippedSteps)
+
+			for i, p := range tr.Model.Parameters() {
+				require.Equal(t, float64(float32(p.Data())), p.Data(), "Master weight %d should be a float32", i)
+			}
+		})
+	}
+}
+
+func TestLossScaleSkipsOverflowingSteps(t *testing.T) {
+	cfg := tinyRun(t)
+	cfg.CheckpointDir = ""
+	cfg.Precision.Activations = "float16"
+	cfg.Precision.LossScale = 1 << 40
+	cfg.Precision.ScaleWindow = 2
+
+	tr, err := New(cfg, nil)
+	require.NoError(t, err)
+	params := tr.Model.Parameters()
+	before := make([]float64, len(params))
+	for i, p := range params {
+		before[i] = p.Data()
+	}
+
+	// The first step overflows float16, leaves the weights alone and
+	// halves the scale
+	_, _, norm, err := tr.Step(0)
+	require.NoError(t, err)
+	assert.True(t, math.IsInf(norm, 1))
+	for i, p := range params {
+		require.Equal(t, before[i], p.Data(), "param %d", i)
+	}
+	assert.Equal(t, float64(1<<39), tr.scaler.scale)
+
+	// The scale keeps halving until steps fit, then training proceeds and
+	// the scale grows again every ScaleWindow good steps
+	fit := 0.0
+	var scales []float64
+	for step := 1; step < 40; step++ {
+		_, _, norm, err := tr.Step(step)
+		require.NoError(t, err)
+		if fit == 0 && !math.IsInf(norm, 1) {
+			fit = tr.scaler.scale
+		}
+		scales = append(scales, tr.scaler.scale)
+	}
+	require.NotZero(t, fit, "Some step should have fit in float16")
+	assert.Greater(t, tr.scaler.skipped, 1)
+	assert.Less(t, fit, float64(1<<39))
+	assert.Greater(t, slices.Max(scales[len(scales)-10:]), fit, "The scale should grow again, got %v", scales)
+	assert.NotEqual(t, before[0], params[0].Data())
+}
+
+func TestLossScaler(t *testing.T) {
+	s := newLossScaler(PrecisionConfig{LossScale: 8, ScaleWindow: 2})
+	p := micrograd.NewValue(0)
+
+	p.SetGrad(4)
+	require.True(t, s.unscale([]*micrograd.Value{p}))
+	assert.Equal(t, 0.5, p.Grad())
+	assert.Equal(t, 8.0, s.scale)
+
+	p.SetGrad(4)
+	require.True(t, s.unscale([]*micrograd.Value{p}))
+	assert.Equal(t, 16.0, s.scale, "Two good steps in a row should double the scale")
+
+	p.SetGrad(math.Inf(1))
+	require.False(t, s.unscale([]*micrograd.Value{p}))
+	assert.Equal(t, 8.0, s.scale)
+	assert.Equal(t, 1, s.skipped)
+}
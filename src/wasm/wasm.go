@@ -0,0 +1,122 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Module runs a graph exported by micrograd's Tape.WriteWasm. The module
+// runs in wazero's sandbox and can only reach the math functions provided
+// here. Its memory is capped at MemoryLimitPages, and a call stops when its
+// context is done, so a module that never returns can be abandoned with a
+// deadline. The sandbox does not bound CPU time on its own: pass a context
+// with a timeout when running modules you did not build.
+//
+// A Module is not safe for concurrent use.
+type Module struct {
+	runtime  wazero.Runtime
+	mod      api.Module
+	forward  api.Function
+	gradient api.Function
+	inputs   int
+}
+
+// MemoryLimitPages is the most 64 KiB pages of memory a module may have,
+// 16 MiB: room for the inputs and gradients of a million input graph.
+const MemoryLimitPages = 256
+
+// Load compiles and instantiates module. Once a call's context is done the
+// module is closed and every later call fails.
+func Load(ctx context.Context, module []byte) (*Module, error) {
+	cfg := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(MemoryLimitPages)
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().WithFunc(math.Exp).Export("exp").
+		NewFunctionBuilder().WithFunc(math.Log).Export("log").
+		NewFunctionBuilder().WithFunc(math.Pow).Export("pow").
+		Instantiate(ctx)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("wasm: host functions: %w", err)
+	}
+
+	mod, err := r.Instantiate(ctx, module)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("wasm: %w", err)
+	}
+
+	m := &Module{
+		runtime:  r,
+		mod:      mod,
+		forward:  mod.ExportedFunction("forward"),
+		gradient: mod.ExportedFunction("gradient"),
+	}
+	inputs := mod.ExportedGlobal("inputs")
+	if m.forward == nil || m.gradient == nil || inputs == nil || mod.Memory() == nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("wasm: module does not export forward, gradient, inputs and memory")
+	}
+	m.inputs = int(api.DecodeI32(inputs.Get()))
+
+	return m, nil
+}
+
+// NumInputs returns the number of inputs the graph takes.
+func (m *Module) NumInputs() int {
+	return m.inputs
+}
+
+// Forward returns the graph's output at x.
+func (m *Module) Forward(ctx context.Context, x []float64) (float64, error) {
+	return m.call(ctx, m.forward, x)
+}
+
+// Gradient returns the graph's output at x and its gradient with respect to
+// each input.
+func (m *Module) Gradient(ctx context.Context, x []float64) (float64, []float64, error) {
+	out, err := m.call(ctx, m.gradient, x)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	grads := make([]float64, m.inputs)
+	for i := range grads {
+		g, ok := m.mod.Memory().ReadFloat64Le(uint32(8 * (m.inputs + i)))
+		if !ok {
+			return 0, nil, fmt.Errorf("wasm: gradient %d is outside memory", i)
+		}
+		grads[i] = g
+	}
+
+	return out, grads, nil
+}
+
+func (m *Module) call(ctx context.Context, fn api.Function, x []float64) (float64, error) {
+	if len(x) != m.inputs {
+		return 0, fmt.Errorf("wasm: module takes %d inputs, got %d", m.inputs, len(x))
+	}
+	for i, v := range x {
+		if !m.mod.Memory().WriteFloat64Le(uint32(8*i), v) {
+			return 0, fmt.Errorf("wasm: input %d is outside memory", i)
+		}
+	}
+
+	res, err := fn.Call(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("wasm: %w", err)
+	}
+
+	return api.DecodeF64(res[0]), nil
+}
+
+func (m *Module) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
@@ -0,0 +1,168 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Grimkey/nanollm/src/micrograd"
+)
+
+func load(t *testing.T, root *micrograd.Value, inputs []*micrograd.Value) (*micrograd.Tape, *Module) {
+	t.Helper()
+	tape, err := micrograd.Compile(root, inputs)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tape.WriteWasm(&buf))
+	m, err := Load(context.Background(), buf.Bytes())
+	require.NoError(t, err)
+	t.Cleanup(func() { m.Close(context.Background()) })
+
+	return tape, m
+}
+
+// assertMatchesTape compares the module with the native tape at x.
+func assertMatchesTape(t *testing.T, tape *micrograd.Tape, m *Module, x []float64) {
+	t.Helper()
+	ctx := context.Background()
+
+	want := tape.Forward(x)
+	tape.Backward()
+	wantGrads := make([]float64, len(x))
+	tape.Grads(wantGrads)
+
+	out, err := m.Forward(ctx, x)
+	require.NoError(t, err)
+	assert.InDelta(t, want, out, 1e-12)
+
+	out, grads, err := m.Gradient(ctx, x)
+	require.NoError(t, err)
+	assert.InDelta(t, want, out, 1e-12)
+	assert.InDeltaSlice(t, wantGrads, grads, 1e-12)
+}
+
+func TestExpression(t *testing.T) {
+	// Every op the engine has, including both sides of Pow.
+	a, b := micrograd.NewValue(-4), micrograd.NewValue(2)
+	c := a.Add(b)
+	d := a.Multiply(b).Add(b.PowScalar(3))
+	e := c.Add(d.ReLU()).Sub(d).Exp().AddScalar(1).Log()
+	f := e.PowScalar(2).Div(d.Neg().AddScalar(1)).Add(b.Multiply(b).Pow(e))
+	f.Backward()
+
+	tape, m := load(t, f, []*micrograd.Value{a, b})
+	assert.Equal(t, 2, m.NumInputs())
+
+	_, grads, err := m.Gradient(context.Background(), []float64{-4, 2})
+	require.NoError(t, err)
+	assert.InDelta(t, a.Grad(), grads[0], 1e-12)
+	assert.InDelta(t, b.Grad(), grads[1], 1e-12)
+
+	for _, x := range [][]float64{{-4, 2}, {3, -1.5}, {0.5, 0}} {
+		assertMatchesTape(t, tape, m, x)
+	}
+}
+
+func TestMLP(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	mlp := micrograd.NewMLP(2, []int{8, 8, 1}, rng)
+	inputs := mlp.Parameters()
+	var scores []*micrograd.Value
+	var labels []float64
+	for i := 0; i < 8; i++ {
+		x := []*micrograd.Value{micrograd.NewValue(rng.Float64()), micrograd.NewValue(rng.Float64())}
+		inputs = append(inputs, x...)
+		scores = append(scores, mlp.Call(x)[0])
+		labels = append(labels, float64(2*(i%2)-1))
+	}
+	loss := micrograd.Hinge(scores, labels)
+	loss.Backward()
+
+	tape, m := load(t, loss, inputs)
+	x := make([]float64, len(inputs))
+	for i, v := range inputs {
+		x[i] = v.Data()
+	}
+
+	_, grads, err := m.Gradient(context.Background(), x)
+	require.NoError(t, err)
+	for i, v := range inputs {
+		assert.InDelta(t, v.Grad(), grads[i], 1e-12, "input %d", i)
+	}
+
+	for i := range x {
+		x[i] *= -0.5
+	}
+	assertMatchesTape(t, tape, m, x)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	_, err := Load(ctx, []byte("not wasm"))
+	assert.Error(t, err)
+
+	a := micrograd.NewValue(1)
+	_, m := load(t, a.Exp(), []*micrograd.Value{a})
+	_, err = m.Forward(ctx, []float64{1, 2})
+	assert.ErrorContains(t, err, "takes 1 inputs")
+}
+
+// spin is a module whose forward and gradient loop forever, with min pages
+// of memory.
+func spin(pages int) []byte {
+	section := func(id byte, body ...byte) []byte {
+		return append([]byte{id, byte(len(body))}, body...)
+	}
+	export := func(name string, kind byte) []byte {
+		return append(append([]byte{byte(len(name))}, name...), kind, 0)
+	}
+
+	var exports []byte
+	exports = append(exports, 4)
+	exports = append(exports, export("forward", 0)...)
+	exports = append(exports, export("gradient", 0)...)
+	exports = append(exports, export("inputs", 3)...)
+	exports = append(exports, export("memory", 2)...)
+
+	// loop br 0 end, then an unreachable f64.const 0 for the result
+	body := []byte{0, 0x03, 0x40, 0x0c, 0, 0x0b, 0x44, 0, 0, 0, 0, 0, 0, 0, 0, 0x0b}
+
+	module := []byte{0, 'a', 's', 'm', 1, 0, 0, 0}
+	module = append(module, section(1, 1, 0x60, 0, 1, 0x7c)...)                             // () -> f64
+	module = append(module, section(3, 1, 0)...)                                            // one function
+	module = append(module, section(5, 1, 0, byte(pages|0x80), byte(pages>>7))...)          // min pages, two byte LEB128
+	module = append(module, section(6, 1, 0x7f, 0, 0x41, 0, 0x0b)...)                       // inputs = 0
+	module = append(module, section(7, exports...)...)                                      // exports
+	module = append(module, section(10, append([]byte{1, byte(len(body))}, body...)...)...) // code
+	return module
+}
+
+func TestCancelStopsRunawayModule(t *testing.T) {
+	m, err := Load(context.Background(), spin(1))
+	require.NoError(t, err)
+	defer m.Close(context.Background())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = m.Forward(ctx, nil)
+	assert.ErrorContains(t, err, "wasm")
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+
+	_, err = m.Forward(context.Background(), nil)
+	assert.Error(t, err, "A cancelled module should stay closed")
+}
+
+func TestMemoryLimit(t *testing.T) {
+	_, err := Load(context.Background(), spin(MemoryLimitPages+1))
+	assert.Error(t, err)
+
+	m, err := Load(context.Background(), spin(MemoryLimitPages))
+	require.NoError(t, err)
+	m.Close(context.Background())
+}
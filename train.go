@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/Grimkey/nanollm/src/metrics"
+	"github.com/Grimkey/nanollm/src/train"
+)
+
+func runTrain(args []string) error {
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	configPath := fs.String("config", "", "YAML config file (defaults are used when empty)")
+	data := fs.String("data", "", "training text file")
+	tok := fs.String("tokenizer", "", "tokenizer: char or byte")
+	contextSize := fs.Int("context-size", 0, "number of previous tokens the model sees")
+	embedDim := fs.Int("embed-dim", 0, "token embedding size")
+	hidden := fs.String("hidden", "", "comma separated hidden layer sizes, e.g. 64,64")
+	optimizer := fs.String("optimizer", "", "optimizer: adamw or sgd")
+	lr := fs.Float64("lr", 0, "peak learning rate")
+	weightDecay := fs.Float64("weight-decay", 0, "weight decay")
+	gradClip := fs.Float64("grad-clip", 0, "max global gradient norm, 0 disables clipping")
+	schedule := fs.String("schedule", "", "learning rate schedule: constant, linear or cosine")
+	warmup := fs.Int("warmup-steps", 0, "learning rate warmup steps")
+	steps := fs.Int("steps", 0, "number of optimizer steps")
+	batchSize := fs.Int("batch-size", 0, "examples per step")
+	workers := fs.Int("workers", 0, "goroutines each batch is split across")
+	gradAccum := fs.Int("grad-accum", 0, "micro-batches each batch is split into, summing their gradients")
+	precision := fs.String("precision", "", "activation dtype: float64, float32, float16 or bfloat16 (mixed precision below float64)")
+	evalInterval := fs.Int("eval-interval", 0, "steps between validation and checkpointing, 0 only at the end")
+	evalBatches := fs.Int("eval-batches", 0, "validation batches per evaluation")
+	checkpointDir := fs.String("checkpoint-dir", "", "directory for latest.json and best.json")
+	metricsFiles := fs.String("metrics", "", "comma separated metric files (.jsonl or .csv), relative to the checkpoint dir")
+	seed := fs.Int64("seed", 0, "random seed")
+	detectAnomaly := fs.Bool("detect-anomaly", false, "stop at the first NaN or Inf, reporting the op that produced it (slow)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := train.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = train.LoadConfig(*configPath); err != nil {
+			return err
+		}
+	}
+
+	// Only flags given on the command line override the config file
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "data":
+			cfg.Data.Path = *data
+		case "tokenizer":
+			cfg.Tokenizer = *tok
+		case "context-size":
+			cfg.Model.ContextSize = *contextSize
+		case "embed-dim":
+			cfg.Model.EmbedDim = *embedDim
+		case "hidden":
+			if cfg.Model.Hidden, err = parseInts(*hidden); err != nil {
+				err = fmt.Errorf("-hidden: %w", err)
+			}
+		case "optimizer":
+			cfg.Optimizer.Name = *optimizer
+		case "lr":
+			cfg.Optimizer.LR = *lr
+		case "weight-decay":
+			cfg.Optimizer.WeightDecay = *weightDecay
+		case "grad-clip":
+			cfg.Optimizer.GradClip = *gradClip
+		case "schedule":
+			cfg.Schedule.Name = *schedule
+		case "warmup-steps":
+			cfg.Schedule.WarmupSteps = *warmup
+		case "steps":
+			cfg.Steps = *steps
+		case "batch-size":
+			cfg.BatchSize = *batchSize
+		case "workers":
+			cfg.Workers = *workers
+		case "grad-accum":
+			cfg.GradAccum = *gradAccum
+		case "precision":
+			cfg.Precision.Activations = *precision
+		case "eval-interval":
+			cfg.EvalInterval = *evalInterval
+		case "eval-batches":
+			cfg.EvalBatches = *evalBatches
+		case "checkpoint-dir":
+			cfg.CheckpointDir = *checkpointDir
+		case "metrics":
+			cfg.Metrics = nil
+			for _, path := range strings.Split(*metricsFiles, ",") {
+				if path = strings.TrimSpace(path); path != "" {
+					cfg.Metrics = append(cfg.Metrics, path)
+				}
+			}
+		case "seed":
+			cfg.Seed = *seed
+		case "detect-anomaly":
+			cfg.DetectAnomaly = *detectAnomaly
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	loggers := []metrics.Logger{metrics.NewTable(os.Stdout, metrics.Columns)}
+	for _, path := range cfg.MetricsPaths() {
+		logger, err := metrics.OpenFile(path)
+		if err != nil {
+			return err
+		}
+		loggers = append(loggers, logger)
+		fmt.Printf("writing metrics to %s, plot them with: nanollm plot --metrics %s\n", path, path)
+	}
+	logger := metrics.Multi(loggers...)
+	defer logger.Close()
+
+	trainer, err := train.New(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("training on %d tokens (%d validation), vocabulary %d, %d parameters\n",
+		len(trainer.Data.Train), len(trainer.Data.Val), trainer.Tokenizer.VocabSize(), len(trainer.Model.Parameters()))
+
+	// Ctrl-C finishes the current step and writes a final checkpoint
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	res, err := trainer.Run(ctx)
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	fmt.Printf("done after %d steps: train loss %.4f, val loss %.4f\n", res.Steps, res.TrainLoss, res.ValLoss)
+	if res.SkippedSteps > 0 {
+		fmt.Printf("%d steps skipped for loss scale overflow\n", res.SkippedSteps)
+	}
+	return nil
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+
+	return out, nil
+}